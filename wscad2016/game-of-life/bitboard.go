@@ -0,0 +1,261 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"math/bits"
+)
+
+// ------------------ Data type -----------
+
+// BitBoard keep 64 cells in a word, column k is bit k%64 of word k/64
+// bits after the last column are always zero
+type BitBoard struct {
+	data     [][]uint64
+	width    int
+	height   int
+	words    int // words per row
+	boundary Boundary
+	rule     Rule
+	stats    Stats // filled by merge_bits, hash differ from the byte kernel
+}
+
+func NewBitBoard(width, height int) BitBoard {
+	words := (width + 63) / 64
+	board := BitBoard{data: make([][]uint64, height), width: width, height: height, words: words, rule: Conway}
+	for i := 0; i < height; i++ {
+		board.data[i] = make([]uint64, words)
+	}
+	return board
+}
+
+type BitRowChunk struct {
+	row_id   int
+	width    int      // number of cells in the row
+	value    []uint64 // current data set
+	result   []uint64 // result data set
+	top      []uint64 // above row of row_value
+	bottom   []uint64 // bottom row of row_value
+	boundary Boundary
+	rule     Rule
+	population int    // live cells of result
+	hash       uint64 // FNV-1a of the result words
+	births     int    // dead in value, alive in result
+	deaths     int    // alive in value, dead in result
+	min_col, max_col int // first and last live column of result, -1 if none
+}
+
+func NewBitRowChunk(id, width int) BitRowChunk {
+	words := (width + 63) / 64
+	rc := BitRowChunk{row_id: id, width: width}
+	rc.value = make([]uint64, words)
+	rc.result = make([]uint64, words)
+	rc.top = make([]uint64, words)
+	rc.bottom = make([]uint64, words)
+	return rc
+}
+
+// neighbours of a word in the row: west (column - 1) and east (column + 1) shifted
+// into place, carry bits come from the words around it
+func shift_row(row []uint64, w int, width int, wrap bool) (uint64, uint64) {
+	words := len(row)
+	x := row[w]
+
+	west := x << 1
+	if w > 0 {
+		west |= row[w - 1] >> 63
+	} else if wrap {
+		last := uint(width - 1)
+		west |= (row[last / 64] >> (last % 64)) & 1
+	}
+
+	east := x >> 1
+	if w + 1 < words {
+		east |= row[w + 1] << 63
+	} else if wrap {
+		east |= (row[0] & 1) << uint((width - 1) % 64)
+	}
+
+	return west, east
+}
+
+// add one bit plane into the bit-sliced counter s0..s3 (ripple half adders)
+func add_plane(s *[4]uint64, m uint64) {
+	c := s[0] & m
+	s[0] ^= m
+	c, s[1] = s[1] & c, s[1] ^ c
+	c, s[2] = s[2] & c, s[2] ^ c
+	s[3] |= c
+}
+
+// mask of the cells whose bit-sliced count equal n
+func count_equal(s *[4]uint64, n uint) uint64 {
+	m := ^uint64(0)
+	for i := uint(0); i < 4; i++ {
+		if n & (1 << i) != 0 {
+			m &= s[i]
+		} else {
+			m &^= s[i]
+		}
+	}
+	return m
+}
+
+// word parallel version of RowChunk.play, 64 cells per step
+func (rc BitRowChunk) play() Chunk {
+	words := len(rc.value)
+	wrap := rc.boundary == BoundaryWrap
+
+	// mask of the valid bits in the last word
+	last := ^uint64(0)
+	if rc.width % 64 != 0 {
+		last = (1 << uint(rc.width % 64)) - 1
+	}
+
+	rc.population, rc.births, rc.deaths = 0, 0, 0
+	rc.min_col, rc.max_col = -1, -1
+	rc.hash = fnv_offset
+
+	for w := 0; w < words; w++ {
+		var s [4]uint64
+
+		for _, row := range [][]uint64{rc.top, rc.bottom} {
+			west, east := shift_row(row, w, rc.width, wrap)
+			add_plane(&s, west)
+			add_plane(&s, row[w])
+			add_plane(&s, east)
+		}
+		west, east := shift_row(rc.value, w, rc.width, wrap)
+		add_plane(&s, west)
+		add_plane(&s, east)
+
+		alive := rc.value[w]
+		next := uint64(0)
+		for n := uint(0); n <= 8; n++ {
+			if rc.rule.birth & (1 << n) != 0 {
+				next |= count_equal(&s, n) &^ alive
+			}
+			if rc.rule.survive & (1 << n) != 0 {
+				next |= count_equal(&s, n) & alive
+			}
+		}
+
+		if w == words - 1 {
+			next &= last
+		}
+		rc.result[w] = next
+		rc.population += bits.OnesCount64(next)
+		rc.hash = (rc.hash ^ next) * fnv_prime
+		rc.births += bits.OnesCount64(next &^ alive)
+		rc.deaths += bits.OnesCount64(alive &^ next)
+		if next != 0 {
+			if rc.min_col < 0 {
+				rc.min_col = w * 64 + bits.TrailingZeros64(next)
+			}
+			rc.max_col = w * 64 + 63 - bits.LeadingZeros64(next)
+		}
+	}
+
+	return rc
+}
+
+// -------------------- problem solving functions ----------------------
+
+func pack_board(b Board) BitBoard {
+	bb := NewBitBoard(b.width, b.height)
+	bb.boundary, bb.rule = b.boundary, b.rule
+	for i := 0; i < b.height; i++ {
+		for k := 0; k < min(len(b.data[i]), b.width); k++ {
+			if b.data[i][k] == 'x' {
+				bb.data[i][k / 64] |= 1 << uint(k % 64)
+			}
+		}
+	}
+	return bb
+}
+
+// stats of a bit board that did not come from merge_bits, same hash as the bit kernel
+func bit_board_stats(bb BitBoard) Stats {
+	stats := NewStats()
+	for i := 0; i < bb.height; i++ {
+		population, min_col, max_col := 0, -1, -1
+		hash := uint64(fnv_offset)
+		for w, word := range bb.data[i] {
+			population += bits.OnesCount64(word)
+			hash = (hash ^ word) * fnv_prime
+			if word != 0 {
+				if min_col < 0 {
+					min_col = w * 64 + bits.TrailingZeros64(word)
+				}
+				max_col = w * 64 + 63 - bits.LeadingZeros64(word)
+			}
+		}
+		stats.add_row(i, population, hash, 0, 0, min_col, max_col)
+	}
+	return stats
+}
+
+func unpack_board(bb BitBoard) Board {
+	b := NewBoard(bb.width, bb.height)
+	b.boundary, b.rule = bb.boundary, bb.rule
+	for i := 0; i < bb.height; i++ {
+		for k := 0; k < bb.width; k++ {
+			b.data[i][k] = set_life_status(int(bb.data[i][k / 64] >> uint(k % 64)) & 1)
+		}
+	}
+	return b
+}
+
+func play_parallel_bits(board BitBoard, in chan <- Chunk, out <-chan Chunk) BitBoard {
+
+	// board splitter, split the board row wise
+	go split_bits(board, in)
+
+	// merge process data and wait until all row processed
+	dst := merge_bits(board.width, board.height, out)
+	dst.boundary, dst.rule = board.boundary, board.rule
+	return dst
+}
+
+func split_bits(b BitBoard, data_in chan <- Chunk) {
+
+	for i := 0; i < b.height; i++ {
+		chunk := NewBitRowChunk(i, b.width)
+		chunk.boundary, chunk.rule = b.boundary, b.rule
+
+		copy(chunk.value, b.data[i])
+
+		// default top, bottom row is empty array, wrap mode take the opposite edge
+		if i > 0 {
+			copy(chunk.top, b.data[i - 1])
+		} else if b.boundary == BoundaryWrap {
+			copy(chunk.top, b.data[b.height - 1])
+		}
+
+		if i + 1 < b.height {
+			copy(chunk.bottom, b.data[i + 1])
+		} else if b.boundary == BoundaryWrap {
+			copy(chunk.bottom, b.data[0])
+		}
+
+		data_in <- chunk
+	}
+}
+
+func merge_bits(width, height int, data_out <-chan Chunk) BitBoard {
+	dst := NewBitBoard(width, height)
+	dst.stats = NewStats()
+
+	for i := 0; i < height; i++ {
+		data, ok := <-data_out
+		if ok {
+			item := data.(BitRowChunk)
+			copy(dst.data[item.row_id], item.result)
+			dst.stats.add_row(item.row_id, item.population, item.hash, item.births, item.deaths, item.min_col, item.max_col)
+		}
+	}
+
+	return dst
+}
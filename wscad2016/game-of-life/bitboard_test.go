@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func run_parallel_bits(board BitBoard, step int) BitBoard {
+	pool := NewWorkerPool(4)
+	defer pool.close()
+
+	for i := 0; i < step; i++ {
+		board = play_parallel_bits(board, pool.in, pool.out)
+	}
+	return board
+}
+
+func Test_pack_board(t *testing.T) {
+	board := new_random_board(130, 20, 3)
+	got := board_rows(unpack_board(pack_board(board)))
+	if strings.Join(got, "\n") != strings.Join(board_rows(board), "\n") {
+		t.Error("pack/unpack changed the board")
+	}
+}
+
+func Test_bits_match_bytes(t *testing.T) {
+	// sizes around the word boundary
+	for _, size := range []int{1, 2, 3, 63, 64, 65, 130} {
+		for _, str := range []string{"B3/S23", "B36/S23", "B3678/S34678", "B2/S", "B0123478/S01234678"} {
+			for _, mode := range []Boundary{BoundaryDead, BoundaryWrap} {
+				rule, _ := parse_rule(str)
+				board := new_random_board(size, size % 7 + 1, int64(size))
+				board.rule, board.boundary = rule, mode
+
+				want := run_parallel(board, 8)
+				got := unpack_board(run_parallel_bits(pack_board(board), 8))
+				if strings.Join(board_rows(got), "\n") != strings.Join(board_rows(want), "\n") {
+					t.Errorf("size %d %s boundary %d: bit kernel differ from byte kernel", size, str, mode)
+				}
+			}
+		}
+	}
+}
+
+// single thread kernel cost of one generation on a 1024x1024 board
+
+func Benchmark_row_chunk_play(b *testing.B) {
+	board := new_random_board(1024, 1024, 1)
+	chunks := make([]RowChunk, board.height)
+	for i := range chunks {
+		chunks[i] = NewRowChunk(i, board.width)
+		chunks[i].rule = board.rule
+		copy(chunks[i].value, board.data[i])
+		if i > 0 {
+			copy(chunks[i].top, board.data[i - 1])
+		}
+		if i + 1 < board.height {
+			copy(chunks[i].bottom, board.data[i + 1])
+		}
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, rc := range chunks {
+			rc.play()
+		}
+	}
+}
+
+func Benchmark_bit_row_chunk_play(b *testing.B) {
+	board := pack_board(new_random_board(1024, 1024, 1))
+	chunks := make([]BitRowChunk, board.height)
+	for i := range chunks {
+		chunks[i] = NewBitRowChunk(i, board.width)
+		chunks[i].rule = board.rule
+		copy(chunks[i].value, board.data[i])
+		if i > 0 {
+			copy(chunks[i].top, board.data[i - 1])
+		}
+		if i + 1 < board.height {
+			copy(chunks[i].bottom, board.data[i + 1])
+		}
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, rc := range chunks {
+			rc.play()
+		}
+	}
+}
+
+// full generation through the worker pool, including split and merge
+
+func Benchmark_play_parallel(b *testing.B) {
+	board := new_random_board(1024, 1024, 1)
+	pool := NewWorkerPool(8)
+	defer pool.close()
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		play_parallel(board, pool.in, pool.out)
+	}
+}
+
+func Benchmark_play_parallel_bits(b *testing.B) {
+	board := pack_board(new_random_board(1024, 1024, 1))
+	pool := NewWorkerPool(8)
+	defer pool.close()
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		play_parallel_bits(board, pool.in, pool.out)
+	}
+}
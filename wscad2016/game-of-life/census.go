@@ -0,0 +1,490 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ------------------ Data type -----------
+
+type ObjectClass int
+
+const (
+	ClassStill ObjectClass = iota
+	ClassOscillator
+	ClassSpaceship
+	ClassDying   // the cluster die out alone
+	ClassUnknown // no repeat within the period limit, a growing pattern or a mess
+)
+
+// key prefix of every class, like the apgsearch codes
+var class_prefix = [...]string{"xs", "xp", "xq", "xd", "ov"}
+
+var class_name = [...]string{"still life", "oscillator", "spaceship", "dying", "unknown"}
+
+// Object is a classified cluster, key is the same for every phase, rotation and reflection
+type Object struct {
+	key    string
+	name   string // from census_names, empty if not known
+	class  ObjectClass
+	period int
+	dr, dc int // displacement of a spaceship in one period
+	cells  int // population of the phase found on the board
+}
+
+type CensusEntry struct {
+	object   Object
+	count    int
+	row, col int // top left cell of the first one on the board
+}
+
+// Census is the object count of a board
+type Census struct {
+	rule     Rule
+	clusters int
+	entries  map[string]*CensusEntry
+}
+
+// B3/S23 objects, 'O' alive, rows separated by '/'
+var census_table = []struct {
+	name, cells string
+}{
+	{"block", "OO/OO"},
+	{"beehive", ".OO./O..O/.OO."},
+	{"loaf", ".OO./O..O/.O.O/..O."},
+	{"boat", "OO./O.O/.O."},
+	{"ship", "OO./O.O/.OO"},
+	{"tub", ".O./O.O/.O."},
+	{"pond", ".OO./O..O/O..O/.OO."},
+	{"long boat", "OO../O.O./.O.O/..O."},
+	{"barge", ".O../O.O./.O.O/..O."},
+	{"mango", ".OO../O..O./.O..O/..OO."},
+	{"snake", "OO.O/O.OO"},
+	{"eater 1", "OO../O.O./..O./..OO"},
+	{"blinker", "OOO"},
+	{"toad", ".OOO/OOO."},
+	{"beacon", "OO../OO../..OO/..OO"},
+	{"clock", "..O./O.O./.O.O/.O.."},
+	{"pulsar", "..OOO...OOO../............./O....O.O....O/O....O.O....O/O....O.O....O/..OOO...OOO../" +
+		"............./..OOO...OOO../O....O.O....O/O....O.O....O/O....O.O....O/............./..OOO...OOO.."},
+	{"pentadecathlon", "..O....O../OO.OOOO.OO/..O....O.."},
+	{"glider", ".O./..O/OOO"},
+	{"LWSS", ".O..O/O..../O...O/OOOO."},
+	{"MWSS", "...O../.O...O/O...../O....O/OOOOO."},
+	{"HWSS", "...OO../.O....O/O....../O.....O/OOOOOO."},
+}
+
+// generations a cluster is run to find its period
+const census_period = 64
+
+var census_names map[string]string
+var census_once sync.Once
+
+// -------------------- problem solving functions ----------------------
+
+func parse_census_cells(str string) [][2]int {
+	var cells [][2]int
+	for r, row := range strings.Split(str, "/") {
+		for c, cell := range row {
+			if cell == 'O' {
+				cells = append(cells, [2]int{r, c})
+			}
+		}
+	}
+	return cells
+}
+
+// key -> name of the table objects, classified once with the B3/S23 rule
+func census_name(key string) string {
+	census_once.Do(func() {
+		census_names = make(map[string]string)
+		pool := NewWorkerPool(1)
+		defer pool.close()
+		for _, o := range census_table {
+			census_names[classify_cluster(parse_census_cells(o.cells), Conway, census_period, pool).key] = o.name
+		}
+	})
+	return census_names[key]
+}
+
+// name of the table object with this key, only known for B3/S23
+func object_name(rule Rule, key string) string {
+	if rule != Conway {
+		return ""
+	}
+	return census_name(key)
+}
+
+// objects are run alone on the unbounded plane, a B0 rule has no empty background
+func check_census_rule(rule Rule) error {
+	if err := check_life_like("Census", rule); err != nil {
+		return err
+	}
+	if rule.birth & 1 != 0 {
+		return errors.New("Census does not support B0 rules")
+	}
+	return nil
+}
+
+// live cells connected when they are at most radius rows and columns apart, a wrap board
+// follow the cluster across the edge and the cells get coordinates off the board
+func find_clusters(b Board, radius int) [][][2]int {
+	seen := make([][]bool, b.height)
+	for i := range seen {
+		seen[i] = make([]bool, b.width)
+	}
+	wrap := b.boundary == BoundaryWrap
+
+	var clusters [][][2]int
+	for i := 0; i < b.height; i++ {
+		for k := 0; k < b.width; k++ {
+			if seen[i][k] || !board_alive(b, i, k) {
+				continue
+			}
+			seen[i][k] = true
+			cluster := [][2]int{{i, k}}
+			for n := 0; n < len(cluster); n++ {
+				cell := cluster[n]
+				for dr := -radius; dr <= radius; dr++ {
+					for dc := -radius; dc <= radius; dc++ {
+						row, col := cell[0] + dr, cell[1] + dc
+						r, c := row, col
+						if wrap {
+							r, c = (r % b.height + b.height) % b.height, (c % b.width + b.width) % b.width
+						}
+						if r < 0 || r >= b.height || c < 0 || c >= b.width || seen[r][c] || !board_alive(b, r, c) {
+							continue
+						}
+						seen[r][c] = true
+						cluster = append(cluster, [2]int{row, col})
+					}
+				}
+			}
+			clusters = append(clusters, cluster)
+		}
+	}
+	return clusters
+}
+
+// clusters of a set of cells on the unbounded plane, connected like find_clusters
+func cell_clusters(cells [][2]int, radius int) [][][2]int {
+	alive := make(map[[2]int]bool, len(cells))
+	for _, cell := range cells {
+		alive[cell] = true
+	}
+	// sorted start cells give the same clusters in the same order for every input order
+	starts := append([][2]int(nil), cells...)
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i][0] < starts[j][0] || starts[i][0] == starts[j][0] && starts[i][1] < starts[j][1]
+	})
+
+	var clusters [][][2]int
+	for _, start := range starts {
+		if !alive[start] {
+			continue
+		}
+		delete(alive, start)
+		cluster := [][2]int{start}
+		for n := 0; n < len(cluster); n++ {
+			cell := cluster[n]
+			for dr := -radius; dr <= radius; dr++ {
+				for dc := -radius; dc <= radius; dc++ {
+					next := [2]int{cell[0] + dr, cell[1] + dc}
+					if alive[next] {
+						delete(alive, next)
+						cluster = append(cluster, next)
+					}
+				}
+			}
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+// cells moved to the top left corner, sorted, with the offset of the corner
+func normalize_cells(cells [][2]int) ([][2]int, int, int) {
+	if len(cells) == 0 {
+		return nil, 0, 0
+	}
+	min_row, min_col := cells[0][0], cells[0][1]
+	for _, cell := range cells {
+		min_row, min_col = min(min_row, cell[0]), min(min_col, cell[1])
+	}
+	dst := make([][2]int, len(cells))
+	for i, cell := range cells {
+		dst[i] = [2]int{cell[0] - min_row, cell[1] - min_col}
+	}
+	sort.Slice(dst, func(i, j int) bool {
+		return dst[i][0] < dst[j][0] || dst[i][0] == dst[j][0] && dst[i][1] < dst[j][1]
+	})
+	return dst, min_row, min_col
+}
+
+// rows of the normalized cells as hex bit masks, bit k is column k, separated by '.'
+func encode_shape(cells [][2]int) string {
+	cells, _, _ = normalize_cells(cells)
+	var rows []string
+	for _, cell := range cells {
+		for len(rows) <= cell[0] {
+			rows = append(rows, "")
+		}
+		rows[cell[0]] = hex_set_bit(rows[cell[0]], cell[1])
+	}
+	return strings.Join(rows, ".")
+}
+
+// hex digits little end first, 4 columns per digit
+func hex_set_bit(row string, col int) string {
+	digits := []byte(row)
+	for len(digits) <= col / 4 {
+		digits = append(digits, '0')
+	}
+	v := strings.IndexByte("0123456789abcdef", digits[col / 4]) | 1 << uint(col % 4)
+	digits[col / 4] = "0123456789abcdef"[v]
+	return string(digits)
+}
+
+// smallest encoding of the 8 rotations and reflections, shorter first
+func canonical_shape(cells [][2]int) string {
+	best := ""
+	for t := 0; t < 8; t++ {
+		moved := make([][2]int, len(cells))
+		for i, cell := range cells {
+			r, c := cell[0], cell[1]
+			if t & 1 != 0 {
+				c = -c
+			}
+			if t & 2 != 0 {
+				r = -r
+			}
+			if t & 4 != 0 {
+				r, c = c, r
+			}
+			moved[i] = [2]int{r, c}
+		}
+		shape := encode_shape(moved)
+		if best == "" || len(shape) < len(best) || len(shape) == len(best) && shape < best {
+			best = shape
+		}
+	}
+	return best
+}
+
+func sparse_cells(s *Sparse) [][2]int {
+	var cells [][2]int
+	for key, tile := range s.tiles {
+		for r := 0; r < tile_size; r++ {
+			for c := 0; c < tile_size; c++ {
+				if tile.cells[r][c] == 1 {
+					cells = append(cells, [2]int{key.row * tile_size + r, key.col * tile_size + c})
+				}
+			}
+		}
+	}
+	return cells
+}
+
+// run the cluster alone on the plane until its first phase come back, moved or not
+func classify_cluster(cells [][2]int, rule Rule, max_period int, pool *WorkerPool) Object {
+	first, _, _ := normalize_cells(cells)
+	o := Object{cells: len(first)}
+
+	s := NewSparse(rule)
+	for _, cell := range first {
+		s.set(cell[0], cell[1], true)
+	}
+	start := encode_shape(first)
+	phases := []string{canonical_shape(first)}
+	populations := []int{len(first)}
+	o.class = ClassUnknown
+	for gen := 1; gen <= max_period; gen++ {
+		play_parallel_sparse(s, pool.in, pool.out)
+		now := sparse_cells(s)
+		if len(now) == 0 {
+			o.class = ClassDying
+			break
+		}
+		if len(now) == len(first) && encode_shape(now) == start {
+			_, dr, dc := normalize_cells(now)
+			o.period, o.dr, o.dc = gen, dr, dc
+			switch {
+			case dr != 0 || dc != 0:
+				o.class = ClassSpaceship
+			case gen == 1:
+				o.class = ClassStill
+			default:
+				o.class = ClassOscillator
+			}
+			break
+		}
+		phases = append(phases, canonical_shape(now))
+		populations = append(populations, len(now))
+	}
+
+	// same key from every phase, the shortest then the smallest, with its population
+	best := 0
+	if o.class != ClassUnknown && o.class != ClassDying {
+		for i, p := range phases {
+			if len(p) < len(phases[best]) || len(p) == len(phases[best]) && p < phases[best] {
+				best = i
+			}
+		}
+	}
+	prefix := class_prefix[o.class]
+	if o.class == ClassOscillator || o.class == ClassSpaceship {
+		prefix += fmt.Sprint(o.period)
+	}
+	o.key = fmt.Sprintf("%s%d_%s", prefix, populations[best], phases[best])
+	return o
+}
+
+// speed of a spaceship, eg: c/4 diagonal, c/2 orthogonal, (2,1)c/6
+func (o Object) velocity() string {
+	if o.class != ClassSpaceship {
+		return ""
+	}
+	dr, dc := abs_int(o.dr), abs_int(o.dc)
+	// c/2 for 2 cells in 4 generations
+	speed := func(n int) string {
+		g := gcd(n, o.period)
+		if n == g {
+			return fmt.Sprintf("c/%d", o.period / g)
+		}
+		return fmt.Sprintf("%dc/%d", n / g, o.period / g)
+	}
+	switch {
+	case dr == 0 || dc == 0:
+		return speed(dr + dc) + " orthogonal"
+	case dr == dc:
+		return speed(dr) + " diagonal"
+	}
+	return fmt.Sprintf("(%d,%d)c/%d", max(dr, dc), min(dr, dc), o.period)
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a % b
+	}
+	return a
+}
+
+func abs_int(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// split the board in clusters and classify them on workers goroutines
+func take_census(b Board, radius, max_period, workers int) (*Census, error) {
+	if err := check_census_rule(b.rule); err != nil {
+		return nil, err
+	}
+	if radius < 1 || max_period < 1 || workers < 1 {
+		return nil, errors.New("Census radius, period and workers must be positive")
+	}
+
+	clusters := find_clusters(b, radius)
+	objects := make([]Object, len(clusters))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			// one sparse universe at a time on a pool of its own
+			pool := NewWorkerPool(1)
+			defer pool.close()
+			for i := range jobs {
+				objects[i] = classify_cluster(clusters[i], b.rule, max_period, pool)
+			}
+		}()
+	}
+	for i := range clusters {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	c := &Census{rule: b.rule, clusters: len(clusters), entries: make(map[string]*CensusEntry)}
+	for i, o := range objects {
+		entry, ok := c.entries[o.key]
+		if !ok {
+			o.name = object_name(b.rule, o.key)
+			_, row, col := normalize_cells(clusters[i])
+			entry = &CensusEntry{object: o, row: row, col: col}
+			c.entries[o.key] = entry
+		}
+		entry.count++
+	}
+	return c, nil
+}
+
+// entries by count, most common first
+func (c *Census) sorted() []*CensusEntry {
+	entries := make([]*CensusEntry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		return entries[i].object.key < entries[j].object.key
+	})
+	return entries
+}
+
+// ------------  input, output -----------
+
+// census of the board to path, - is stderr
+func write_census(b Board, path string, radius, max_period, workers int) error {
+	c, err := take_census(b, radius, max_period, workers)
+	if err != nil {
+		return err
+	}
+	if path == "-" {
+		return c.write(os.Stderr)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = c.write(file)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (c *Census) write(w io.Writer) error {
+	fmt.Fprintf(w, "census of %d clusters, rule %v\n", c.clusters, c.rule)
+	fmt.Fprintf(w, "%6s  %-16s %-11s %6s  %-16s %5s  %-9s  %s\n", "count", "name", "class", "period", "velocity", "cells", "first", "key")
+	for _, entry := range c.sorted() {
+		o := entry.object
+		name := o.name
+		if name == "" {
+			name = "-"
+		}
+		period := ""
+		if o.period > 1 {
+			period = fmt.Sprint(o.period)
+		}
+		_, err := fmt.Fprintf(w, "%6d  %-16s %-11s %6s  %-16s %5d  %-9s  %s\n", entry.count, name, class_name[o.class], period,
+			o.velocity(), o.cells, fmt.Sprintf("%d,%d", entry.row, entry.col), o.key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_census_table(t *testing.T) {
+	want := map[string]struct {
+		class    ObjectClass
+		period   int
+		velocity string
+	}{
+		"block": {ClassStill, 1, ""}, "beehive": {ClassStill, 1, ""}, "eater 1": {ClassStill, 1, ""},
+		"blinker": {ClassOscillator, 2, ""}, "beacon": {ClassOscillator, 2, ""}, "clock": {ClassOscillator, 2, ""},
+		"pulsar": {ClassOscillator, 3, ""}, "pentadecathlon": {ClassOscillator, 15, ""},
+		"glider": {ClassSpaceship, 4, "c/4 diagonal"}, "LWSS": {ClassSpaceship, 4, "c/2 orthogonal"},
+		"HWSS": {ClassSpaceship, 4, "c/2 orthogonal"},
+	}
+
+	pool := NewWorkerPool(1)
+	defer pool.close()
+	keys := make(map[string]string)
+	for _, entry := range census_table {
+		cells := parse_census_cells(entry.cells)
+		o := classify_cluster(cells, Conway, census_period, pool)
+		if other, ok := keys[o.key]; ok {
+			t.Errorf("%s and %s have the same key %s", entry.name, other, o.key)
+		}
+		keys[o.key] = entry.name
+		if o.class == ClassUnknown || o.class == ClassDying {
+			t.Errorf("%s: not a still life, an oscillator or a spaceship", entry.name)
+		}
+		if w, ok := want[entry.name]; ok && (o.class != w.class || o.period != w.period || o.velocity() != w.velocity) {
+			t.Errorf("%s: got %s period %d %q", entry.name, class_name[o.class], o.period, o.velocity())
+		}
+
+		// every rotation and reflection of a later phase get the same name
+		for tr := 0; tr < 8; tr++ {
+			moved := make([][2]int, len(cells))
+			for i, cell := range cells {
+				r, c := cell[0], cell[1]
+				if tr & 1 != 0 {
+					c = -c
+				}
+				if tr & 2 != 0 {
+					r = -r
+				}
+				if tr & 4 != 0 {
+					r, c = c, r
+				}
+				moved[i] = [2]int{r, c}
+			}
+			s := NewSparse(Conway)
+			for _, cell := range moved {
+				s.set(cell[0] + 100, cell[1] + 100, true)
+			}
+			play_parallel_sparse(s, pool.in, pool.out)
+			if got := classify_cluster(sparse_cells(s), Conway, census_period, pool); census_name(got.key) != entry.name {
+				t.Errorf("%s transform %d: got key %s named %q", entry.name, tr, got.key, census_name(got.key))
+			}
+		}
+	}
+}
+
+func Test_find_clusters(t *testing.T) {
+	// blocks 2 columns apart are one cluster with radius 2, the third one is 3 columns away
+	board, _, _ := read_input(strings.NewReader("16 4 0\n\nxx xx   xx\nxx xx   xx\n"))
+	if got := len(find_clusters(board, 2)); got != 2 {
+		t.Errorf("radius 2: got %d clusters, want 2", got)
+	}
+	if got := len(find_clusters(board, 1)); got != 3 {
+		t.Errorf("radius 1: got %d clusters, want 3", got)
+	}
+
+	// a blinker across the wrap edge is one cluster with cells off the board
+	board, _, _ = read_input(strings.NewReader("6 6 0\n  x\n\n\n\n  x\n  x\n"))
+	board.boundary = BoundaryWrap
+	clusters := find_clusters(board, 1)
+	if len(clusters) != 1 || len(clusters[0]) != 3 {
+		t.Fatalf("got clusters %v", clusters)
+	}
+	if shape := encode_shape(clusters[0]); shape != "1.1.1" {
+		t.Errorf("got shape %s, want a vertical line", shape)
+	}
+}
+
+func Test_census_board(t *testing.T) {
+	rows := []string{
+		"",
+		" xx        x         ",
+		" xx        x     xx  ",
+		"           x    x  x ",
+		"                 xx  ",
+		"",
+		"  x        xxx       ",
+		"   x                 ",
+		" xxx  xx         xx  ",
+		"      xx         xx  ",
+	}
+	board, _, _ := read_input(strings.NewReader("22 11 0\n" + strings.Join(rows, "\n")))
+	c, err := take_census(board, 2, census_period, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	for _, entry := range c.entries {
+		counts[entry.object.name] += entry.count
+	}
+	for name, want := range map[string]int{"block": 3, "blinker": 2, "beehive": 1, "glider": 1} {
+		if counts[name] != want {
+			t.Errorf("got %d %s, want %d", counts[name], name, want)
+		}
+	}
+	if c.clusters != 7 {
+		t.Errorf("got %d clusters, want 7", c.clusters)
+	}
+
+	buf := &bytes.Buffer{}
+	c.write(buf)
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.Contains(lines[2], "block") || !strings.Contains(buf.String(), "c/4 diagonal") {
+		t.Errorf("got report:\n%s", buf.String())
+	}
+}
+
+func Test_census_unsupported(t *testing.T) {
+	board := new_random_board(10, 10, 1)
+	board.rule, _ = parse_rule("/2/3")
+	if _, err := take_census(board, 2, census_period, 1); err == nil {
+		t.Error("expected error for a Generations rule")
+	}
+	board.rule, _ = parse_rule("B013/S23")
+	if _, err := take_census(board, 2, census_period, 1); err == nil {
+		t.Error("expected error for a B0 rule")
+	}
+}
+
+func Test_cell_clusters(t *testing.T) {
+	// blinker far off the board and a block, in any order
+	cells := [][2]int{{-500, 7}, {0, 0}, {-500, 8}, {1, 1}, {-500, 9}, {0, 1}, {1, 0}}
+	reversed := make([][2]int, len(cells))
+	for i, cell := range cells {
+		reversed[len(cells) - 1 - i] = cell
+	}
+	a, b := cell_clusters(cells, 2), cell_clusters(reversed, 2)
+	if len(a) != 2 || len(a[0]) != 3 || len(a[1]) != 4 || encode_shape(a[0]) != encode_shape(b[0]) {
+		t.Errorf("got clusters %v and %v", a, b)
+	}
+}
@@ -0,0 +1,221 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math/bits"
+	"os"
+	"path/filepath"
+)
+
+// ------------------ Data type -----------
+
+// Checkpoint is the state of a run, enough to continue it to the same final board
+type Checkpoint struct {
+	board      Board
+	generation int
+	target     int // generation the run stop at
+}
+
+// binary file, integers are little endian:
+//	"GOLC" version:uint8 boundary:uint8 rule_len:uint16 rule width:uint32 height:uint32
+//	generation:uint64 target:uint64 cells crc32:uint32
+// cells are packed row after row with the fewest bits that hold a state, the last byte is zero padded,
+// crc32 (IEEE) cover everything before it
+const (
+	checkpoint_magic   = "GOLC"
+	checkpoint_version = 1
+)
+
+// Checkpointer save the engine board every n generations
+type Checkpointer struct {
+	path   string
+	every  int
+	target int
+	err    error // first save error
+}
+
+// -------------------- problem solving functions ----------------------
+
+func write_checkpoint(w io.Writer, c Checkpoint) error {
+	b := c.board
+	rule := b.rule.String()
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(checkpoint_magic)
+	buf.WriteByte(checkpoint_version)
+	buf.WriteByte(byte(b.boundary))
+	binary.Write(buf, binary.LittleEndian, uint16(len(rule)))
+	buf.WriteString(rule)
+	binary.Write(buf, binary.LittleEndian, [2]uint32{uint32(b.width), uint32(b.height)})
+	binary.Write(buf, binary.LittleEndian, [2]uint64{uint64(c.generation), uint64(c.target)})
+
+	// bit stream, acc hold the bits not written yet
+	width := bits.Len(uint(b.rule.states() - 1))
+	acc, n := uint64(0), 0
+	for i := 0; i < b.height; i++ {
+		for k := 0; k < b.width; k++ {
+			acc = acc << uint(width) | uint64(board_state(b, i, k))
+			n += width
+			for n >= 8 {
+				buf.WriteByte(byte(acc >> uint(n - 8)))
+				n -= 8
+			}
+		}
+	}
+	if n > 0 {
+		buf.WriteByte(byte(acc << uint(8 - n)))
+	}
+
+	binary.Write(buf, binary.LittleEndian, crc32.ChecksumIEEE(buf.Bytes()))
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func read_checkpoint(rd io.Reader) (Checkpoint, error) {
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	invalid := errors.New("Invalid checkpoint")
+
+	if len(data) < 8 || string(data[:4]) != checkpoint_magic {
+		return Checkpoint{}, invalid
+	}
+	body, sum := data[:len(data) - 4], binary.LittleEndian.Uint32(data[len(data) - 4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return Checkpoint{}, errors.New("Invalid checkpoint: checksum mismatch")
+	}
+	if body[4] != checkpoint_version {
+		return Checkpoint{}, fmt.Errorf("Invalid checkpoint: version %d", body[4])
+	}
+
+	buf := bytes.NewReader(body[5:])
+	var boundary byte
+	var rule_len uint16
+	binary.Read(buf, binary.LittleEndian, &boundary)
+	if err := binary.Read(buf, binary.LittleEndian, &rule_len); err != nil || int(rule_len) > buf.Len() {
+		return Checkpoint{}, invalid
+	}
+	rule_str := make([]byte, rule_len)
+	buf.Read(rule_str)
+	var size [2]uint32
+	var gen [2]uint64
+	binary.Read(buf, binary.LittleEndian, &size)
+	if err := binary.Read(buf, binary.LittleEndian, &gen); err != nil {
+		return Checkpoint{}, invalid
+	}
+
+	rule, err := parse_rule(string(rule_str))
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if boundary > byte(BoundaryWrap) {
+		return Checkpoint{}, invalid
+	}
+
+	// the cells must fill the rest exactly, a broken size never allocate a huge board,
+	// an empty side would pass with no cells, a cell is at least one bit
+	width := uint64(bits.Len(uint(rule.states() - 1)))
+	area := uint64(size[0]) * uint64(size[1])
+	if area == 0 || area > uint64(buf.Len()) * 8 {
+		return Checkpoint{}, invalid
+	}
+	cells := (area * width + 7) / 8
+	if cells != uint64(buf.Len()) {
+		return Checkpoint{}, invalid
+	}
+
+	b := NewBoard(int(size[0]), int(size[1]))
+	b.boundary, b.rule = Boundary(boundary), rule
+	acc, n := uint64(0), uint64(0)
+	mask := uint64(1) << width - 1
+	for i := 0; i < b.height; i++ {
+		for k := 0; k < b.width; k++ {
+			for n < width {
+				next, _ := buf.ReadByte()
+				acc = acc << 8 | uint64(next)
+				n += 8
+			}
+			n -= width
+			b.data[i][k] = state_cell(int(acc >> n & mask))
+		}
+	}
+
+	return Checkpoint{board: b, generation: int(gen[0]), target: int(gen[1])}, nil
+}
+
+// write the checkpoint to a temporary file next to path and rename it,
+// a crash leave the previous checkpoint or the new one, never a partial file
+func save_checkpoint(path string, c Checkpoint) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path) + ".tmp*")
+	if err != nil {
+		return err
+	}
+
+	err = write_checkpoint(tmp, c)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func load_checkpoint(path string) (Checkpoint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	defer file.Close()
+	return read_checkpoint(file)
+}
+
+// engine of the checkpoint board, generations continue from the checkpoint
+func NewEngineFromCheckpoint(c Checkpoint, kernel string, workers int) (*Engine, error) {
+	e, err := NewEngine(c.board, kernel, workers)
+	if err == nil {
+		e.generation = c.generation
+	}
+	return e, err
+}
+
+// save the current board now
+func (e *Engine) SaveCheckpoint(path string, target int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return save_checkpoint(path, Checkpoint{board: e.current(), generation: e.generation, target: target})
+}
+
+// save the board every n generations the engine play, errors are kept in the checkpointer
+func (e *Engine) CheckpointEvery(path string, every, target int) (*Checkpointer, error) {
+	if every < 1 {
+		return nil, errors.New("Checkpoint interval must be positive")
+	}
+
+	cp := &Checkpointer{path: path, every: every, target: target}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.observers = append(e.observers, func(generation int, s Stats) {
+		if generation % cp.every == 0 && cp.err == nil {
+			cp.err = save_checkpoint(cp.path, Checkpoint{board: e.current(), generation: generation, target: cp.target})
+		}
+	})
+	return cp, nil
+}
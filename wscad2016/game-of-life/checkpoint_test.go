@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_checkpoint_round_trip(t *testing.T) {
+	for _, str := range []string{"B3/S23", "345/2/4", "12/34/26", "R2,C0,M1,S4..9,B5..6,NN"} {
+		board := run_parallel(new_random_board(13, 7, 5), 3)
+		board.rule, _ = parse_rule(str)
+		board = run_parallel(board, 4) // Generations rules leave dying states
+		board.boundary = BoundaryWrap
+
+		buf := &bytes.Buffer{}
+		if err := write_checkpoint(buf, Checkpoint{board: board, generation: 7, target: 1 << 40}); err != nil {
+			t.Fatal(err)
+		}
+		c, err := read_checkpoint(buf)
+		if err != nil {
+			t.Fatalf("%s: %v", str, err)
+		}
+		if c.generation != 7 || c.target != 1 << 40 || c.board.boundary != BoundaryWrap || c.board.rule != board.rule {
+			t.Errorf("%s: got generation %d target %d boundary %d rule %v", str, c.generation, c.target, c.board.boundary, c.board.rule)
+		}
+		if !same_board(c.board, board) {
+			t.Errorf("%s: got\n%s\nwant\n%s", str, strings.Join(board_rows(c.board), "\n"), strings.Join(board_rows(board), "\n"))
+		}
+	}
+}
+
+func Test_checkpoint_compact(t *testing.T) {
+	buf := &bytes.Buffer{}
+	write_checkpoint(buf, Checkpoint{board: new_random_board(64, 64, 1)})
+	// header, rule, one bit per cell and the checksum
+	if want := 4 + 2 + 2 + len("B3/S23") + 8 + 16 + 64 * 64 / 8 + 4; buf.Len() != want {
+		t.Errorf("got %d bytes, want %d", buf.Len(), want)
+	}
+}
+
+func Test_checkpoint_corrupt(t *testing.T) {
+	buf := &bytes.Buffer{}
+	write_checkpoint(buf, Checkpoint{board: new_random_board(20, 10, 1), generation: 3})
+	data := buf.Bytes()
+
+	flipped := append([]byte(nil), data...)
+	flipped[30] ^= 1
+	for name, bad := range map[string][]byte{
+		"empty":     nil,
+		"magic":     append([]byte("GOLX"), data[4:]...),
+		"truncated": data[:len(data) - 9],
+		"flipped":   flipped,
+	} {
+		if _, err := read_checkpoint(bytes.NewReader(bad)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	// a valid checksum over a size with no cells or more cells than bits
+	header := 4 + 2 + 2 + len("B3/S23")
+	for _, size := range [][2]uint32{{0, 4000000000}, {4000000000, 0}, {1 << 31, 1 << 31}} {
+		body := append([]byte(nil), data[:header]...)
+		body = binary.LittleEndian.AppendUint32(body, size[0])
+		body = binary.LittleEndian.AppendUint32(body, size[1])
+		body = append(body, data[header + 8:header + 24]...)
+		body = binary.LittleEndian.AppendUint32(body, crc32.ChecksumIEEE(body))
+		if _, err := read_checkpoint(bytes.NewReader(body)); err == nil {
+			t.Errorf("size %v: expected error", size)
+		}
+	}
+}
+
+func Test_save_checkpoint_atomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.ckpt")
+
+	for gen := 1; gen <= 2; gen++ {
+		if err := save_checkpoint(path, Checkpoint{board: new_random_board(10, 10, 1), generation: gen}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the second save replace the first one and leave no temporary file
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Errorf("got %d files in the directory, want 1", len(files))
+	}
+	if c, err := load_checkpoint(path); err != nil || c.generation != 2 {
+		t.Errorf("got generation %d %v, want 2", c.generation, err)
+	}
+
+	if err := save_checkpoint(filepath.Join(dir, "missing", "run.ckpt"), Checkpoint{}); err == nil {
+		t.Error("expected error for a missing directory")
+	}
+}
+
+func Test_resume_match_uninterrupted(t *testing.T) {
+	for _, kernel := range []string{"byte", "bit", "table"} {
+		board := new_soup_board(60, 24, 9)
+		board.boundary = BoundaryWrap
+		want := board_rows(new_test_engine_steps(t, board, kernel, 100))
+
+		// interrupt the first run at generation 73, the last periodic checkpoint is 60
+		path := filepath.Join(t.TempDir(), "run.ckpt")
+		ctx, cancel := context.WithCancel(context.Background())
+		e, _ := NewEngine(board, kernel, 4)
+		e.Start(ctx)
+		cp, err := e.CheckpointEvery(path, 20, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.observers = append(e.observers, func(generation int, s Stats) {
+			if generation == 73 {
+				cancel()
+			}
+		})
+		if err := e.Step(100); err == nil {
+			t.Fatalf("%s: run was not interrupted", kernel)
+		}
+		if c, _ := load_checkpoint(path); cp.err != nil || c.generation != 60 {
+			t.Fatalf("%s: periodic checkpoint at generation %d %v, want 60", kernel, c.generation, cp.err)
+		}
+		if err := e.SaveCheckpoint(path, 100); err != nil {
+			t.Fatal(err)
+		}
+		e.Close()
+
+		c, err := load_checkpoint(path)
+		if err != nil || c.generation != 73 || c.target != 100 {
+			t.Fatalf("%s: got generation %d target %d %v", kernel, c.generation, c.target, err)
+		}
+		resumed, err := NewEngineFromCheckpoint(c, kernel, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resumed.Start(context.Background())
+		defer resumed.Close()
+		resumed.Step(c.target - resumed.Generation())
+
+		if resumed.Generation() != 100 {
+			t.Errorf("%s: resumed run stopped at generation %d", kernel, resumed.Generation())
+		}
+		if got := board_rows(resumed.Snapshot()); strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%s: resumed run differ from the uninterrupted run", kernel)
+		}
+	}
+}
+
+func new_test_engine_steps(t *testing.T, board Board, kernel string, n int) Board {
+	e := new_test_engine(t, board, kernel)
+	e.Step(n)
+	return e.Snapshot()
+}
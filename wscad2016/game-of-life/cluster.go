@@ -0,0 +1,446 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// ------------------ Data type -----------
+
+// a cluster run is one coordinator and n worker processes, each worker own a horizontal band:
+//
+//	worker -> coordinator  hello: peer address the band above dial
+//	coordinator -> worker  setup, band cells
+//	worker <-> worker      halo rows of every generation, the worker dial the band below
+//	worker -> coordinator  band cells after the last generation, or error
+//
+// every message is type:uint8 length:uint32 payload, integers are little endian
+const (
+	msg_hello = iota + 1
+	msg_setup
+	msg_band
+	msg_row
+	msg_error
+)
+
+// a message larger than this is a broken stream, never allocated
+const max_message = 1 << 30
+
+// BandSetup is what a worker need to play its band
+type BandSetup struct {
+	index, count  int // band index of count bands
+	row0, rows    int // first board row and number of rows of the band
+	width, height int // of the whole board
+	halo          int // rows exchanged with each neighbour
+	steps         int
+	boundary      Boundary
+	rule          Rule
+	down          string // peer address of the band below, empty at a dead edge
+	up            bool   // the band above dial this worker
+}
+
+// Coordinator split a board in bands for the workers that join it and gather the result
+type Coordinator struct {
+	ln     net.Listener
+	nodes  int
+	mu     sync.Mutex
+	conns  []net.Conn
+	closed bool
+}
+
+// BandWorker play one band, halo rows come from the workers of the bands above and below
+type BandWorker struct {
+	setup BandSetup
+	band  [][]byte
+	up    net.Conn // nil at a dead edge
+	down  net.Conn
+	pool  *WorkerPool
+}
+
+// -------------------- problem solving functions ----------------------
+
+func write_message(w io.Writer, kind byte, payload []byte) error {
+	buf := make([]byte, 5 + len(payload))
+	buf[0] = kind
+	binary.LittleEndian.PutUint32(buf[1:], uint32(len(payload)))
+	copy(buf[5:], payload)
+	_, err := w.Write(buf)
+	return err
+}
+
+func read_message(r io.Reader) (byte, []byte, error) {
+	var head [5]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	size := binary.LittleEndian.Uint32(head[1:])
+	if size > max_message {
+		return 0, nil, fmt.Errorf("Message of %d bytes is too large", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return head[0], payload, nil
+}
+
+// read a message of the given type, a msg_error is returned as error
+func expect_message(r io.Reader, kind byte) ([]byte, error) {
+	got, payload, err := read_message(r)
+	if err != nil {
+		return nil, err
+	}
+	if got == msg_error {
+		return nil, errors.New(string(payload))
+	}
+	if got != kind {
+		return nil, fmt.Errorf("Unexpected message %d, want %d", got, kind)
+	}
+	return payload, nil
+}
+
+// rows as one state byte per cell
+func encode_rows(rows [][]byte, width int) []byte {
+	buf := make([]byte, 0, len(rows) * width)
+	for _, row := range rows {
+		for k := 0; k < width; k++ {
+			cell := byte(' ')
+			if k < len(row) {
+				cell = row[k]
+			}
+			buf = append(buf, byte(cell_state(cell)))
+		}
+	}
+	return buf
+}
+
+func decode_rows(data []byte, rows, width int) ([][]byte, error) {
+	if len(data) != rows * width {
+		return nil, fmt.Errorf("Got %d cells, want %d rows of %d", len(data), rows, width)
+	}
+	dst := make([][]byte, rows)
+	for i := range dst {
+		dst[i] = make([]byte, width)
+		for k := range dst[i] {
+			dst[i][k] = state_cell(int(data[i * width + k]))
+		}
+	}
+	return dst, nil
+}
+
+func encode_setup(s BandSetup) []byte {
+	buf := &bytes.Buffer{}
+	up := uint32(0)
+	if s.up {
+		up = 1
+	}
+	binary.Write(buf, binary.LittleEndian, [10]uint32{uint32(s.index), uint32(s.count), uint32(s.row0), uint32(s.rows),
+		uint32(s.width), uint32(s.height), uint32(s.halo), uint32(s.boundary), up, 0})
+	binary.Write(buf, binary.LittleEndian, uint64(s.steps))
+	for _, str := range []string{s.rule.String(), s.down} {
+		binary.Write(buf, binary.LittleEndian, uint16(len(str)))
+		buf.WriteString(str)
+	}
+	return buf.Bytes()
+}
+
+func decode_setup(data []byte) (BandSetup, error) {
+	buf := bytes.NewReader(data)
+	var v [10]uint32
+	var steps uint64
+	binary.Read(buf, binary.LittleEndian, &v)
+	if err := binary.Read(buf, binary.LittleEndian, &steps); err != nil {
+		return BandSetup{}, errors.New("Invalid setup message")
+	}
+	var str [2]string
+	for i := range str {
+		var size uint16
+		if err := binary.Read(buf, binary.LittleEndian, &size); err != nil || int(size) > buf.Len() {
+			return BandSetup{}, errors.New("Invalid setup message")
+		}
+		field := make([]byte, size)
+		buf.Read(field)
+		str[i] = string(field)
+	}
+	rule, err := parse_rule(str[0])
+	if err != nil {
+		return BandSetup{}, err
+	}
+	s := BandSetup{index: int(v[0]), count: int(v[1]), row0: int(v[2]), rows: int(v[3]), width: int(v[4]), height: int(v[5]),
+		halo: int(v[6]), boundary: Boundary(v[7]), up: v[8] == 1, steps: int(steps), rule: rule, down: str[1]}
+	if s.boundary > BoundaryWrap || s.rows < s.halo || s.halo < 1 || s.rows > s.height {
+		return BandSetup{}, errors.New("Invalid setup message")
+	}
+	return s, nil
+}
+
+// rows exchanged with a neighbour, Larger than Life need radius rows
+func band_halo(r Rule) int {
+	return max(1, r.radius)
+}
+
+// split height rows in n bands that differ by at most one row, rows of band i start at row0[i]
+func split_bands(height, n int) []int {
+	row0 := make([]int, n + 1)
+	for i := 0; i <= n; i++ {
+		row0[i] = i * height / n
+	}
+	return row0
+}
+
+func NewCoordinator(addr string, nodes int) (*Coordinator, error) {
+	if nodes < 1 {
+		return nil, errors.New("Cluster need at least one node")
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Coordinator{ln: ln, nodes: nodes}, nil
+}
+
+func (c *Coordinator) Addr() string {
+	return c.ln.Addr().String()
+}
+
+// close the listener and the worker connections, a running run return an error
+func (c *Coordinator) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for _, conn := range c.conns {
+		conn.Close()
+	}
+	return c.ln.Close()
+}
+
+// wait for the workers, play steps generations of b on them and gather the final board
+func (c *Coordinator) run(b Board, steps int) (Board, error) {
+	if err := check_topology(b); err != nil {
+		return Board{}, err
+	}
+	if err := check_ltl(b); err != nil {
+		return Board{}, err
+	}
+	halo := band_halo(b.rule)
+	row0 := split_bands(b.height, c.nodes)
+	for i := 0; i < c.nodes; i++ {
+		if row0[i + 1] - row0[i] < halo {
+			return Board{}, fmt.Errorf("Board of %d rows is too small for %d bands of at least %d rows", b.height, c.nodes, halo)
+		}
+	}
+
+	// workers in the order they joined, band i is the i-th one
+	peers := make([]string, c.nodes)
+	for i := 0; i < c.nodes; i++ {
+		conn, err := c.ln.Accept()
+		if err != nil {
+			return Board{}, err
+		}
+		c.mu.Lock()
+		c.conns = append(c.conns, conn)
+		c.mu.Unlock()
+		payload, err := expect_message(conn, msg_hello)
+		if err != nil {
+			return Board{}, fmt.Errorf("node %d: %v", i, err)
+		}
+		peers[i] = string(payload)
+	}
+
+	for i, conn := range c.conns {
+		s := BandSetup{index: i, count: c.nodes, row0: row0[i], rows: row0[i + 1] - row0[i], width: b.width, height: b.height,
+			halo: halo, steps: steps, boundary: b.boundary, rule: b.rule}
+		if i + 1 < c.nodes {
+			s.down = peers[i + 1]
+		} else if b.boundary == BoundaryWrap {
+			s.down = peers[0]
+		}
+		s.up = i > 0 || b.boundary == BoundaryWrap
+		err := write_message(conn, msg_setup, encode_setup(s))
+		if err == nil {
+			err = write_message(conn, msg_band, encode_rows(b.data[s.row0:s.row0 + s.rows], b.width))
+		}
+		if err != nil {
+			return Board{}, fmt.Errorf("node %d: %v", i, err)
+		}
+	}
+
+	// gather the bands, the first error win
+	dst := NewBoard(b.width, b.height)
+	dst.boundary, dst.rule = b.boundary, b.rule
+	errs := make(chan error, c.nodes)
+	for i, conn := range c.conns {
+		go func(i int, conn net.Conn) {
+			payload, err := expect_message(conn, msg_band)
+			var rows [][]byte
+			if err == nil {
+				rows, err = decode_rows(payload, row0[i + 1] - row0[i], b.width)
+			}
+			if err != nil {
+				errs <- fmt.Errorf("node %d: %v", i, err)
+				return
+			}
+			copy(dst.data[row0[i]:], rows)
+			errs <- nil
+		}(i, conn)
+	}
+	var first error
+	for i := 0; i < c.nodes; i++ {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+			// unblock the workers waiting for a neighbour that failed
+			c.close()
+		}
+	}
+	return dst, first
+}
+
+// join the coordinator at join, listen on peer for the band above, play the band with workers goroutines
+func run_worker(join, peer string, workers int) error {
+	ln, err := net.Listen("tcp", peer)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	conn, err := net.Dial("tcp", join)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := write_message(conn, msg_hello, []byte(ln.Addr().String())); err != nil {
+		return err
+	}
+	w, err := NewBandWorker(conn, ln, workers)
+	if err == nil {
+		defer w.close()
+		err = w.run()
+	}
+	if err != nil {
+		// tell the coordinator, it may still be waiting for the band
+		write_message(conn, msg_error, []byte(err.Error()))
+		return err
+	}
+	return write_message(conn, msg_band, encode_rows(w.band, w.setup.width))
+}
+
+// read setup and band from the coordinator and connect to the neighbours
+func NewBandWorker(conn net.Conn, ln net.Listener, workers int) (*BandWorker, error) {
+	payload, err := expect_message(conn, msg_setup)
+	if err != nil {
+		return nil, err
+	}
+	w := &BandWorker{}
+	if w.setup, err = decode_setup(payload); err != nil {
+		return nil, err
+	}
+	if payload, err = expect_message(conn, msg_band); err != nil {
+		return nil, err
+	}
+	if w.band, err = decode_rows(payload, w.setup.rows, w.setup.width); err != nil {
+		return nil, err
+	}
+
+	// every worker listen before its hello, the dial wait in the backlog until the accept
+	if w.setup.down != "" {
+		if w.down, err = net.Dial("tcp", w.setup.down); err != nil {
+			return nil, err
+		}
+	}
+	if w.setup.up {
+		if w.up, err = ln.Accept(); err != nil {
+			w.close()
+			return nil, err
+		}
+	}
+	w.pool = NewWorkerPool(workers)
+	return w, nil
+}
+
+func (w *BandWorker) close() {
+	for _, conn := range []net.Conn{w.up, w.down} {
+		if conn != nil {
+			conn.Close()
+		}
+	}
+	if w.pool != nil {
+		w.pool.close()
+	}
+}
+
+// send own edge rows and receive the halo rows, empty at a dead edge
+func (w *BandWorker) exchange(generation int) ([][]byte, [][]byte, error) {
+	s := w.setup
+	header := make([]byte, 8)
+	binary.LittleEndian.PutUint64(header, uint64(generation))
+
+	// send in the background, both neighbours send before they receive
+	errs := make(chan error, 2)
+	send := func(conn net.Conn, rows [][]byte) {
+		errs <- write_message(conn, msg_row, append(header, encode_rows(rows, s.width)...))
+	}
+	receive := func(conn net.Conn) ([][]byte, error) {
+		if conn == nil {
+			return NewBoard(s.width, s.halo).data, nil
+		}
+		payload, err := expect_message(conn, msg_row)
+		if err != nil {
+			return nil, err
+		}
+		if len(payload) < 8 || binary.LittleEndian.Uint64(payload) != uint64(generation) {
+			return nil, errors.New("Halo row of another generation")
+		}
+		return decode_rows(payload[8:], s.halo, s.width)
+	}
+
+	sent := 0
+	if w.up != nil {
+		go send(w.up, w.band[:s.halo])
+		sent++
+	}
+	if w.down != nil {
+		go send(w.down, w.band[s.rows - s.halo:])
+		sent++
+	}
+	top, err := receive(w.up)
+	var bottom [][]byte
+	if err == nil {
+		bottom, err = receive(w.down)
+	}
+	for i := 0; i < sent; i++ {
+		if serr := <-errs; err == nil {
+			err = serr
+		}
+	}
+	return top, bottom, err
+}
+
+// play the band, the halo rows make the band rows exact, the halo results are dropped
+func (w *BandWorker) run() error {
+	s := w.setup
+	// hexagonal and triangular cells take their shape from the row parity, an empty row above
+	// the halo keep the parity of the board rows
+	pad := (s.row0 - s.halo) & 1
+	for generation := 0; generation < s.steps; generation++ {
+		top, bottom, err := w.exchange(generation)
+		if err != nil {
+			return fmt.Errorf("band %d generation %d: %v", s.index, generation, err)
+		}
+		data := append(append(append(NewBoard(s.width, pad).data, top...), w.band...), bottom...)
+		ext := Board{data: data, width: s.width, height: len(data)}
+		ext.boundary, ext.rule = s.boundary, s.rule
+		next := play_parallel(ext, w.pool.in, w.pool.out)
+		w.band = next.data[pad + s.halo:pad + s.halo + s.rows]
+	}
+	return nil
+}
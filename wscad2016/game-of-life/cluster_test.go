@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// coordinator and n workers on goroutines, every message go through localhost TCP
+func run_cluster(t *testing.T, board Board, nodes, steps int) (Board, error) {
+	c, err := NewCoordinator("127.0.0.1:0", nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.close()
+
+	errs := make(chan error, nodes)
+	for i := 0; i < nodes; i++ {
+		go func() {
+			errs <- run_worker(c.Addr(), "127.0.0.1:0", 2)
+		}()
+	}
+	dst, err := c.run(board, steps)
+	c.close()
+	for i := 0; i < nodes; i++ {
+		<-errs
+	}
+	return dst, err
+}
+
+func Test_cluster_match_rows(t *testing.T) {
+	for _, str := range []string{"B3/S23", "345/2/4", "R2,C0,M1,S4..9,B5..6,NN"} {
+		for _, mode := range []Boundary{BoundaryDead, BoundaryWrap} {
+			for _, nodes := range []int{1, 2, 3} {
+				board := new_random_board(37, 23, int64(nodes))
+				board.rule, _ = parse_rule(str)
+				board.boundary = mode
+
+				want := run_parallel(board, 20)
+				got, err := run_cluster(t, board, nodes, 20)
+				if err != nil {
+					t.Fatalf("%s boundary %d nodes %d: %v", str, mode, nodes, err)
+				}
+				if !same_board(got, want) {
+					t.Errorf("%s boundary %d nodes %d: got\n%s\nwant\n%s", str, mode, nodes,
+						strings.Join(board_rows(got), "\n"), strings.Join(board_rows(want), "\n"))
+				}
+			}
+		}
+	}
+}
+
+func Test_cluster_glider_cross_bands(t *testing.T) {
+	// torus of 4 bands of 3 rows, the glider cross every band edge and the wrap edge
+	board, _, _ := read_input(strings.NewReader("10 12 0\n x\n  x\nxxx\n"))
+	board.boundary = BoundaryWrap
+	want := run_parallel(board, 48)
+	got, err := run_cluster(t, board, 4, 48)
+	if err != nil || !same_board(got, want) {
+		t.Errorf("got %v\n%s", err, strings.Join(board_rows(got), "\n"))
+	}
+}
+
+func Test_cluster_thin_bands(t *testing.T) {
+	// radius 2 need bands of 2 rows, 5 rows do not fit 3 of them
+	board := new_random_board(10, 5, 1)
+	board.rule, _ = parse_rule("R2,C0,M1,S4..9,B5..6,NN")
+	c, err := NewCoordinator("127.0.0.1:0", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.close()
+	if _, err := c.run(board, 1); err == nil {
+		t.Error("expected error for bands thinner than the halo")
+	}
+}
+
+func Test_cluster_message(t *testing.T) {
+	buf := &bytes.Buffer{}
+	write_message(buf, msg_row, []byte("abc"))
+	write_message(buf, msg_error, []byte("band failed"))
+	if kind, payload, err := read_message(buf); kind != msg_row || string(payload) != "abc" || err != nil {
+		t.Errorf("got %d %q %v", kind, payload, err)
+	}
+	if _, err := expect_message(buf, msg_band); err == nil || err.Error() != "band failed" {
+		t.Errorf("got %v, want the worker error", err)
+	}
+
+	// a length past max_message is not allocated
+	if _, _, err := read_message(bytes.NewReader([]byte{msg_band, 0xff, 0xff, 0xff, 0xff})); err == nil {
+		t.Error("expected error for a huge message")
+	}
+
+	s := BandSetup{index: 1, count: 3, row0: 8, rows: 7, width: 30, height: 22, halo: 2, steps: 1 << 33,
+		boundary: BoundaryWrap, up: true, down: "127.0.0.1:4000"}
+	s.rule, _ = parse_rule("R2,C0,M1,S4..9,B5..6,NN")
+	if got, err := decode_setup(encode_setup(s)); err != nil || got != s {
+		t.Errorf("got %+v %v", got, err)
+	}
+}
+
+// worker process of Test_cluster_processes, skipped in a normal run
+func Test_cluster_worker_process(t *testing.T) {
+	join := os.Getenv("LIFE_CLUSTER_JOIN")
+	if join == "" {
+		t.Skip("started by Test_cluster_processes")
+	}
+	if err := run_worker(join, "127.0.0.1:0", 2); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_cluster_processes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("start worker processes")
+	}
+	c, err := NewCoordinator("127.0.0.1:0", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.close()
+
+	var procs []*exec.Cmd
+	for i := 0; i < 3; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^Test_cluster_worker_process$")
+		cmd.Env = append(os.Environ(), "LIFE_CLUSTER_JOIN=" + c.Addr())
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		procs = append(procs, cmd)
+	}
+
+	board := new_soup_board(48, 24, 3)
+	board.boundary = BoundaryWrap
+	got, err := c.run(board, 60)
+	for _, cmd := range procs {
+		if werr := cmd.Wait(); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := run_parallel(board, 60); !same_board(got, want) {
+		t.Errorf("got\n%s", strings.Join(board_rows(got), "\n"))
+	}
+}
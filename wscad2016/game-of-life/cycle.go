@@ -0,0 +1,155 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ------------------ Data type -----------
+
+// Cycle is a repeating state found by the detection
+type Cycle struct {
+	period int // 1 for a still state
+	start  int // first generation of the cycle
+	extinct bool
+	found  int // generation when the repeat was seen
+}
+
+// CycleDetector remember the board hash of the last window generations
+type CycleDetector struct {
+	window int
+	seen   map[uint64]int // hash -> latest generation with it
+	ring   []uint64       // hash of generation g is at g % (window + 1)
+	first  int            // oldest generation still in the window
+}
+
+func NewCycleDetector(window int) *CycleDetector {
+	return &CycleDetector{window: window, seen: make(map[uint64]int), ring: make([]uint64, window + 1)}
+}
+
+func (c Cycle) String() string {
+	switch {
+	case c.extinct:
+		return fmt.Sprintf("extinct at generation %d", c.start)
+	case c.period == 1:
+		return fmt.Sprintf("still at generation %d", c.start)
+	}
+	return fmt.Sprintf("period %d oscillator from generation %d", c.period, c.start)
+}
+
+// -------------------- problem solving functions ----------------------
+
+// add the hash of generation gen, return the latest earlier generation with
+// the same hash, at most window generations back
+func (d *CycleDetector) add(gen int, hash uint64) (int, bool) {
+	// expire the generation that fall out of the window
+	for gen - d.first > d.window {
+		old := d.ring[d.first % len(d.ring)]
+		if d.seen[old] == d.first {
+			delete(d.seen, old)
+		}
+		d.first++
+	}
+
+	prev, ok := d.seen[hash]
+	d.ring[gen % len(d.ring)] = hash
+	d.seen[hash] = gen
+
+	return prev, ok
+}
+
+func (e *Engine) stats() Stats {
+	if e.kernel == "bit" {
+		if e.bits.stats == (Stats{}) {
+			return bit_board_stats(e.bits)
+		}
+		return e.bits.stats
+	}
+	if e.board.stats == (Stats{}) {
+		return board_stats(e.board)
+	}
+	return e.board.stats
+}
+
+// run n generations like Step, but stop as soon as the board repeat a state of
+// the last window generations and jump to the final generation arithmetically,
+// return nil when no cycle was found. The skipped generations are not observed,
+// the observers are called once more with the final generation after the jump,
+// the stats writer mark it with the number of skipped generations
+func (e *Engine) Run(n int, window int) (*Cycle, error) {
+	if window < 1 {
+		return nil, errors.New("Cycle window must be positive")
+	}
+
+	e.mu.Lock()
+	target := e.generation + n
+	gen := e.generation
+	e.mu.Unlock()
+
+	detector := NewCycleDetector(window)
+	detector.first = gen
+	var cycle *Cycle
+
+	for gen < target {
+		e.mu.Lock()
+		stats := e.stats()
+		e.mu.Unlock()
+
+		prev, ok := detector.add(gen, stats.hash)
+		if ok && cycle == nil {
+			period := gen - prev
+			cycle = &Cycle{period: period, start: prev, extinct: stats.population == 0, found: gen}
+
+			if target - gen >= period {
+				// confirm with a full compare, a hash collision must not skip generations
+				before := e.Snapshot()
+				if err := e.Step(period); err != nil {
+					return nil, err
+				}
+				if !same_board(before, e.Snapshot()) {
+					cycle = nil
+					gen = e.Generation()
+					continue
+				}
+
+				if err := e.Step((target - e.Generation()) % period); err != nil {
+					return nil, err
+				}
+				e.mu.Lock()
+				if e.generation != target {
+					e.generation = target
+					for _, observe := range e.observers {
+						observe(e.generation, e.stats())
+					}
+				}
+				e.mu.Unlock()
+				return cycle, nil
+			}
+		}
+
+		if err := e.Step(1); err != nil {
+			return cycle, err
+		}
+		gen++
+	}
+
+	return cycle, nil
+}
+
+func same_board(a, b Board) bool {
+	if a.width != b.width || a.height != b.height {
+		return false
+	}
+	for i := 0; i < a.height; i++ {
+		for k := 0; k < a.width; k++ {
+			if board_state(a, i, k) != board_state(b, i, k) {
+				return false
+			}
+		}
+	}
+	return true
+}
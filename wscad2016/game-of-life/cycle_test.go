@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func new_test_engine(t *testing.T, board Board, kernel string) *Engine {
+	e, err := NewEngine(board, kernel, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Start(context.Background())
+	t.Cleanup(func() { e.Close() })
+	return e
+}
+
+func Test_cycle_detector_window(t *testing.T) {
+	d := NewCycleDetector(3)
+	for gen, hash := range []uint64{1, 2, 3, 4, 5} {
+		if _, ok := d.add(gen, hash); ok {
+			t.Fatalf("generation %d: unexpected repeat", gen)
+		}
+	}
+
+	// hash of generation 2 is 3 generation back, still in the window
+	if prev, ok := d.add(5, 3); !ok || prev != 2 {
+		t.Errorf("got %d %v, want 2", prev, ok)
+	}
+	// hash of generation 1 fell out of the window
+	if _, ok := d.add(6, 2); ok {
+		t.Error("repeat outside the window")
+	}
+}
+
+func Test_run_cycles(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    []string
+		period  int
+		start   int
+		extinct bool
+	}{
+		{"block", []string{"xx", "xx"}, 1, 0, false},
+		{"blinker", []string{"", " x", " x", " x"}, 2, 0, false},
+		{"single cell", []string{"", " x"}, 1, 1, true},
+		{"pre-block", []string{"xx", "x"}, 1, 1, false},
+	}
+
+	for _, kernel := range []string{"byte", "bit", "table"} {
+		for _, tc := range tests {
+			board := new_test_board(t, append(tc.rows, "", "", "", "", ""))
+			e := new_test_engine(t, board, kernel)
+
+			cycle, err := e.Run(1000000001, 8)
+			if err != nil || cycle == nil {
+				t.Fatalf("%s %s: no cycle %v", kernel, tc.name, err)
+			}
+			if cycle.period != tc.period || cycle.start != tc.start || cycle.extinct != tc.extinct {
+				t.Errorf("%s %s: got %+v", kernel, tc.name, *cycle)
+			}
+			if e.Generation() != 1000000001 {
+				t.Errorf("%s %s: generation %d", kernel, tc.name, e.Generation())
+			}
+		}
+	}
+}
+
+func Test_run_glider_torus(t *testing.T) {
+	board := new_test_board(t, glider)
+	board.boundary = BoundaryWrap
+
+	// glider on a 8x8 torus is back after 32 generations
+	e := new_test_engine(t, board, "byte")
+	cycle, _ := e.Run(32 * 1000 + 7, 40)
+	if cycle == nil || cycle.period != 32 || cycle.start != 0 {
+		t.Fatalf("got %v", cycle)
+	}
+	want := strings.Join(board_rows(run_parallel(board, 7)), "\n")
+	if strings.Join(board_rows(e.Snapshot()), "\n") != want {
+		t.Error("wrong board after the jump")
+	}
+
+	// period longer than the window is not found
+	e = new_test_engine(t, board, "byte")
+	if cycle, _ := e.Run(100, 20); cycle != nil {
+		t.Errorf("got %v with a window of 20", cycle)
+	}
+}
+
+func Test_run_observe_jump(t *testing.T) {
+	board := new_test_board(t, []string{"", " x", " x", " x", "", ""})
+	e := new_test_engine(t, board, "byte")
+
+	var gens []int
+	e.observers = append(e.observers, func(generation int, s Stats) {
+		gens = append(gens, generation)
+	})
+	var buf bytes.Buffer
+	sw, _ := NewStatsWriter(&buf, "jsonl")
+	e.WriteStats(sw)
+
+	// blinker found at generation 2, confirmed in 2 generations and 1 more to the phase of 1001
+	if cycle, _ := e.Run(1001, 8); cycle == nil || cycle.period != 2 {
+		t.Fatalf("got %v", cycle)
+	}
+	sw.close()
+	if fmt.Sprint(gens) != "[1 2 3 4 5 1001]" {
+		t.Errorf("observed generations %v", gens)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if want := "{\"generation\":1001,\"population\":3,\"births\":2,\"deaths\":2,\"bbox\":{\"min_row\":2,\"min_col\":0,\"max_row\":2,\"max_col\":2},\"skipped\":995}"; lines[len(lines) - 1] != want {
+		t.Errorf("got %s, want %s", lines[len(lines) - 1], want)
+	}
+	if strings.Contains(lines[len(lines) - 2], "skipped") {
+		t.Errorf("played generation marked as a jump: %s", lines[len(lines) - 2])
+	}
+}
+
+func Test_run_match_step(t *testing.T) {
+	for _, kernel := range []string{"byte", "bit", "table"} {
+		for seed := int64(1); seed <= 4; seed++ {
+			board := new_random_board(24, 24, seed)
+			board.boundary = BoundaryWrap
+
+			plain := new_test_engine(t, board, kernel)
+			plain.Step(3000)
+
+			detect := new_test_engine(t, board, kernel)
+			cycle, err := detect.Run(3000, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !same_board(plain.Snapshot(), detect.Snapshot()) || detect.Generation() != 3000 {
+				t.Errorf("%s seed %d: Run(%v) differ from Step", kernel, seed, cycle)
+			}
+		}
+	}
+}
@@ -0,0 +1,214 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"math/bits"
+)
+
+// ------------------ Data type -----------
+
+// Dirty remember which cells the last generation changed, one bit per column of every row.
+// A cell of the next generation only depend on its 3x3 neighbourhood, a cell where none of
+// the cells around changed stay the same and is not played, a row without such cell is not sent
+// to the workers at all. It is valid for the rule and boundary that made it, changing them play
+// every row again.
+type Dirty struct {
+	changed  [][]uint64   // bit set of the changed columns of every row, nil if the row did not change
+	summary  []RowSummary // of every row, reused for the rows that are not played
+	rule     Rule
+	boundary Boundary
+}
+
+func NewDirty(b Board) *Dirty {
+	return &Dirty{changed: make([][]uint64, b.height), summary: make([]RowSummary, b.height), rule: b.rule, boundary: b.boundary}
+}
+
+// -------------------- problem solving functions ----------------------
+
+func (rc *RowChunk) mark_changed(k int) {
+	if rc.changed == nil {
+		rc.changed = make([]uint64, (len(rc.value) + 63) / 64)
+	}
+	rc.changed[k / 64] |= 1 << uint(k % 64)
+}
+
+// rows that can change in the next generation with the columns to play, nil columns play the whole row,
+// every row of a board that was not played
+func active_rows(b Board) ([]int, [][]uint64) {
+	d := b.dirty
+	valid := d != nil && d.rule == b.rule && d.boundary == b.boundary && len(d.changed) == b.height
+
+	rows := make([]int, 0, b.height)
+	columns := make([][]uint64, 0, b.height)
+	for i := 0; i < b.height; i++ {
+		if !valid {
+			rows, columns = append(rows, i), append(columns, nil)
+			continue
+		}
+		mask := active_columns(d.changed, i, b.width, b.boundary == BoundaryWrap, b.rule.topology.reach())
+		if mask == nil {
+			continue
+		}
+		count := 0
+		for _, word := range mask {
+			count += bits.OnesCount64(word)
+		}
+		if count * 4 >= b.width {
+			// a quarter of the row or more, the single pass kernel is faster
+			mask = nil
+		}
+		rows, columns = append(rows, i), append(columns, mask)
+	}
+	return rows, columns
+}
+
+// columns of row i up to reach columns away from a changed cell of rows i-1, i or i+1, nil if there is none
+func active_columns(changed [][]uint64, i int, width int, wrap bool, reach int) []uint64 {
+	height := len(changed)
+	var around []uint64
+	for d := -1; d <= 1; d++ {
+		row := i + d
+		if wrap {
+			row = (row + height) % height
+		}
+		if row < 0 || row >= height || changed[row] == nil {
+			continue
+		}
+		if around == nil {
+			around = make([]uint64, len(changed[row]))
+		}
+		for w, word := range changed[row] {
+			around[w] |= word
+		}
+	}
+	if around == nil {
+		return nil
+	}
+
+	mask := around
+	for ; reach > 0; reach-- {
+		mask = dilate_columns(mask, width, wrap)
+	}
+	return mask
+}
+
+// set the column on each side of every set column, carry over the word edges
+func dilate_columns(set []uint64, width int, wrap bool) []uint64 {
+	mask := make([]uint64, len(set))
+	for w, word := range set {
+		mask[w] |= word | word << 1 | word >> 1
+		if w + 1 < len(set) {
+			mask[w + 1] |= word >> 63
+			mask[w] |= set[w + 1] << 63
+		}
+	}
+	last := width - 1
+	if wrap && width > 0 {
+		// first and last column are neighbours on a torus
+		if set[0] & 1 != 0 {
+			mask[last / 64] |= 1 << uint(last % 64)
+		}
+		if set[last / 64] & (1 << uint(last % 64)) != 0 {
+			mask[0] |= 1
+		}
+	}
+	// drop the bit shifted past the last column
+	if width % 64 != 0 {
+		mask[len(mask) - 1] &= 1 << uint(width % 64) - 1
+	}
+	return mask
+}
+
+// play only the cells of the active columns, the others keep their value,
+// summary cover the whole row like play
+func (rc RowChunk) play_columns() Chunk {
+	size := len(rc.value)
+	wrap := rc.boundary == BoundaryWrap
+	copy(rc.result, rc.value)
+	rc.changed = nil
+	rc.births, rc.deaths = 0, 0
+
+	// live cells of column k in the three rows
+	column := func(k int) int {
+		if k < 0 || k >= size {
+			if !wrap {
+				return 0
+			}
+			k = (k + size) % size
+		}
+		return count_X(rc.top[k], rc.bottom[k], rc.value[k])
+	}
+
+	// sliding window of three columns along a run of active columns
+	left, middle, right, prev := 0, 0, 0, -2
+	for_each_bit(rc.columns, func(k int) {
+		if k == prev + 1 {
+			left, middle, right = middle, right, column(k + 1)
+		} else {
+			left, middle, right = column(k - 1), column(k), column(k + 1)
+		}
+		prev = k
+
+		current := 0
+		if rc.value[k] == 'x' {
+			current = 1
+		}
+		rc.update(k, left + middle + right - current)
+	})
+
+	rc.summarize()
+	return rc
+}
+
+func for_each_bit(set []uint64, fn func(k int)) {
+	for w, word := range set {
+		for ; word != 0; word &= word - 1 {
+			fn(w * 64 + bits.TrailingZeros64(word))
+		}
+	}
+}
+
+// result of cell k from its live neighbours, count births and deaths and mark the change
+func (rc *RowChunk) update(k, neighbour int) {
+	current := 0
+	if rc.value[k] == 'x' {
+		current = 1
+	}
+	life := game_of_life_status(rc.rule, current, neighbour)
+	if rc.rule.wireworld {
+		rc.result[k] = wireworld_cell(rc.value[k], neighbour)
+	} else if rc.rule.dying > 0 {
+		rc.result[k] = generations_cell(rc.rule, rc.value[k], life)
+	} else {
+		rc.result[k] = set_life_status(life)
+	}
+
+	if rc.result[k] != rc.value[k] {
+		rc.mark_changed(k)
+		if rc.result[k] == 'x' {
+			rc.births++
+		} else if current == 1 {
+			rc.deaths++
+		}
+	}
+}
+
+// population, hash and live columns of the whole result row
+func (rc *RowChunk) summarize() {
+	rc.population, rc.min_col, rc.max_col = 0, -1, -1
+	rc.hash = fnv_offset
+	for k, cell := range rc.result {
+		state := cell_state(cell)
+		if state == 1 {
+			rc.population++
+			if rc.min_col < 0 {
+				rc.min_col = k
+			}
+			rc.max_col = k
+		}
+		rc.hash = (rc.hash ^ uint64(state)) * fnv_prime
+	}
+}
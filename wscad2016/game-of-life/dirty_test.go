@@ -0,0 +1,178 @@
+package main
+
+import (
+	"math/bits"
+	"os"
+	"strings"
+	"testing"
+)
+
+// play without the dirty rows, every row every generation
+func run_parallel_full(board Board, step int) Board {
+	pool := NewWorkerPool(4)
+	defer pool.close()
+
+	for i := 0; i < step; i++ {
+		board.dirty = nil
+		board = play_parallel(board, pool.in, pool.out)
+	}
+	return board
+}
+
+func Test_dirty_match_full(t *testing.T) {
+	for _, str := range []string{"B3/S23", "/2/3", "B36/S23", "B0/S8"} {
+		for _, mode := range []Boundary{BoundaryDead, BoundaryWrap} {
+			board := new_soup_board(64, 24, 5)
+			board.rule, _ = parse_rule(str)
+			board.boundary = mode
+
+			want := run_parallel_full(board, 150)
+			got := run_parallel(board, 150)
+			if strings.Join(board_rows(got), "\n") != strings.Join(board_rows(want), "\n") {
+				t.Errorf("%s boundary %d: dirty rows result differ from full play", str, mode)
+			}
+			if got.stats != want.stats {
+				t.Errorf("%s boundary %d: stats %+v, want %+v", str, mode, got.stats, want.stats)
+			}
+		}
+	}
+}
+
+func Test_dirty_active_rows(t *testing.T) {
+	rows := make([]string, 32)
+	for i := range rows {
+		rows[i] = strings.Repeat(" ", 128)
+	}
+	rows[10] = "  x" + strings.Repeat(" ", 125)
+	rows[11] = "  x" + strings.Repeat(" ", 125)
+	rows[12] = "  x" + strings.Repeat(" ", 125)
+	rows[25] = strings.Repeat(" ", 100) + "xx" + strings.Repeat(" ", 26)
+	rows[26] = strings.Repeat(" ", 100) + "xx" + strings.Repeat(" ", 26)
+	board, _, _ := read_input(strings.NewReader("128 32 0\n" + strings.Join(rows, "\n")))
+
+	// a board that was not played has every row active
+	if got, columns := active_rows(board); len(got) != 32 || columns[0] != nil {
+		t.Fatalf("got %d active rows, want 32 whole rows", len(got))
+	}
+
+	// blinker change rows 10 to 12 around column 2, the block is still
+	board = run_parallel(board, 3)
+	got, columns := active_rows(board)
+	if len(got) != 5 || got[0] != 9 || got[4] != 13 {
+		t.Errorf("got active rows %v, want 9 to 13", got)
+	}
+	// the horizontal phase changed (10,2), (12,2), (11,1) and (11,3)
+	for n, want := range []uint64{0b01110, 0b11111, 0b11111, 0b11111, 0b01110} {
+		if len(columns[n]) != 2 || columns[n][0] != want || columns[n][1] != 0 {
+			t.Errorf("row %d: got columns %b, want %b", got[n], columns[n], want)
+		}
+	}
+
+	// an edited rule play every row again
+	board.rule, _ = parse_rule("B36/S23")
+	if got, _ := active_rows(board); len(got) != 32 {
+		t.Errorf("rule changed: got %d active rows, want 32", len(got))
+	}
+}
+
+func Test_dirty_wrap_edge(t *testing.T) {
+	board := new_test_board(t, glider)
+	board.boundary = BoundaryWrap
+
+	// glider cross the bottom edge into row 0, tracked rows must follow it
+	want := board
+	for i := 0; i < 40; i++ {
+		want = play_reference(want)
+	}
+	if got := run_parallel(board, 40); strings.Join(board_rows(got), "\n") != strings.Join(board_rows(want), "\n") {
+		t.Errorf("got:\n%s", strings.Join(board_rows(got), "\n"))
+	}
+
+	// a wide torus, the glider go through the last column of the row into the first one
+	rows := make([]string, 12)
+	for i := range rows {
+		rows[i] = strings.Repeat(" ", 200)
+	}
+	rows[1] = strings.Repeat(" ", 193) + "x" + strings.Repeat(" ", 6)
+	rows[2] = strings.Repeat(" ", 194) + "x" + strings.Repeat(" ", 5)
+	rows[3] = strings.Repeat(" ", 192) + "xxx" + strings.Repeat(" ", 5)
+	board, _, _ = read_input(strings.NewReader("200 12 0\n" + strings.Join(rows, "\n")))
+	board.boundary = BoundaryWrap
+	want = run_parallel_full(board, 80)
+	if got := run_parallel(board, 80); strings.Join(board_rows(got), "\n") != strings.Join(board_rows(want), "\n") || got.stats != want.stats {
+		t.Errorf("column edge: got:\n%s", strings.Join(board_rows(got), "\n"))
+	}
+}
+
+// generations of life.in, every row every generation against the dirty rows
+
+func benchmark_life_in(b *testing.B, run func(Board, int) Board) {
+	file, err := os.Open("life.in")
+	if err != nil {
+		b.Skip(err)
+	}
+	board, step, err := read_input(file)
+	file.Close()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		run(board, step)
+	}
+}
+
+func Benchmark_life_in_full(b *testing.B) {
+	benchmark_life_in(b, run_parallel_full)
+}
+
+func Benchmark_life_in_dirty(b *testing.B) {
+	benchmark_life_in(b, run_parallel)
+}
+
+// random soups, 1024x1024 after 1000 generations still has active regions everywhere,
+// 512x512 after 3000 generations is mostly still lifes and blinkers
+
+func benchmark_soup(b *testing.B, size, step int, full bool) {
+	board := run_parallel(new_random_board(size, size, 1), step)
+	pool := NewWorkerPool(8)
+	defer pool.close()
+
+	b.ResetTimer()
+	active, played := 0, 0
+	for n := 0; n < b.N; n++ {
+		if full {
+			board.dirty = nil
+		}
+		rows, columns := active_rows(board)
+		active += len(rows)
+		for _, mask := range columns {
+			if mask == nil {
+				played += board.width
+			}
+			for _, word := range mask {
+				played += bits.OnesCount64(word)
+			}
+		}
+		board = play_parallel(board, pool.in, pool.out)
+	}
+	b.ReportMetric(float64(active) / float64(b.N), "rows/gen")
+	b.ReportMetric(float64(played) / float64(b.N), "cells/gen")
+}
+
+func Benchmark_soup_full(b *testing.B) {
+	benchmark_soup(b, 1024, 1000, true)
+}
+
+func Benchmark_soup_dirty(b *testing.B) {
+	benchmark_soup(b, 1024, 1000, false)
+}
+
+func Benchmark_soup_settled_full(b *testing.B) {
+	benchmark_soup(b, 512, 3000, true)
+}
+
+func Benchmark_soup_settled_dirty(b *testing.B) {
+	benchmark_soup(b, 512, 3000, false)
+}
@@ -0,0 +1,203 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ------------------ Data type -----------
+
+// WorkerPool play chunks on a fixed number of goroutines until it is closed
+type WorkerPool struct {
+	in   chan Chunk
+	out  chan Chunk
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+func NewWorkerPool(size int) *WorkerPool {
+	p := &WorkerPool{in: make(chan Chunk), out: make(chan Chunk)}
+	p.wg.Add(size)
+	for i := 0; i < size; i++ {
+		go func() {
+			defer p.wg.Done()
+			// every chunk sent to in has a merge waiting for its result,
+			// so a worker never block on out after the last chunk
+			for data := range p.in {
+				p.out <- data.play()
+			}
+		}()
+	}
+	return p
+}
+
+// stop the workers and wait until all of them returned, no chunk may be in flight
+func (p *WorkerPool) close() {
+	p.once.Do(func() {
+		close(p.in)
+		p.wg.Wait()
+	})
+}
+
+// Engine is a reusable simulation of a board on the row-parallel worker pool:
+//
+//	e, _ := NewEngine(board, "byte", runtime.NumCPU())
+//	e.Start(ctx)
+//	defer e.Close()
+//	e.Step(100)
+//	board := e.Snapshot()
+//
+// Step, Snapshot and Close are safe to call from different goroutines.
+type Engine struct {
+	mu         sync.Mutex
+	board      Board
+	bits       BitBoard // current board of the bit kernel
+	kernel     string
+	workers    int
+	pool       *WorkerPool // set by Start, nil while the engine never ran
+	ctx        context.Context
+	stop       context.CancelFunc // also set by Close, Start is refused after it
+	done       chan bool // closed when the pool is shut down
+	generation int
+	observers  []func(generation int, stats Stats) // called after every generation and after a jump of Run
+}
+
+func NewEngine(board Board, kernel string, workers int) (*Engine, error) {
+	if kernel != "byte" && kernel != "bit" && kernel != "table" {
+		return nil, errors.New("Invalid kernel: " + kernel)
+	}
+	if workers < 1 {
+		return nil, errors.New("Engine need at least one worker")
+	}
+	if kernel == "bit" {
+		if err := check_life_like("Bit kernel", board.rule); err != nil {
+			return nil, err
+		}
+	}
+	if kernel == "table" {
+		if err := check_life_like("Table kernel", board.rule); err != nil {
+			return nil, err
+		}
+	}
+	if err := check_topology(board); err != nil {
+		return nil, err
+	}
+	if err := check_ltl(board); err != nil {
+		return nil, err
+	}
+	return &Engine{board: clone_board(board), kernel: kernel, workers: workers}, nil
+}
+
+// -------------------- problem solving functions ----------------------
+
+// deep copy, the rows of a board are not shared
+func clone_board(b Board) Board {
+	dst := b
+	dst.data = make([][]byte, len(b.data))
+	for i := range b.data {
+		dst.data[i] = append([]byte(nil), b.data[i]...)
+	}
+	return dst
+}
+
+// start the worker pool, the engine shut down when ctx is canceled or Close is called
+func (e *Engine) Start(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.stop != nil {
+		return errors.New("Engine is already started")
+	}
+
+	e.ctx, e.stop = context.WithCancel(ctx)
+	e.done = make(chan bool)
+	e.pool = NewWorkerPool(e.workers)
+	if e.kernel == "bit" {
+		e.bits = pack_board(e.board)
+	}
+
+	go func() {
+		<-e.ctx.Done()
+		// wait for a running Step to see the cancellation and return
+		e.mu.Lock()
+		e.pool.close()
+		e.mu.Unlock()
+		close(e.done)
+	}()
+
+	return nil
+}
+
+// run n generations, return the context error when it was canceled between two generations
+func (e *Engine) Step(n int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.pool == nil {
+		return errors.New("Engine is not started")
+	}
+
+	for i := 0; i < n; i++ {
+		if err := e.ctx.Err(); err != nil {
+			return err
+		}
+
+		if e.kernel == "bit" {
+			e.bits = play_parallel_bits(e.bits, e.pool.in, e.pool.out)
+		} else if e.kernel == "table" {
+			e.board = play_parallel_table(e.board, e.pool.in, e.pool.out)
+		} else {
+			e.board = play_parallel(e.board, e.pool.in, e.pool.out)
+		}
+		e.generation++
+
+		for _, observe := range e.observers {
+			observe(e.generation, e.stats())
+		}
+	}
+
+	return nil
+}
+
+// copy of the current board
+func (e *Engine) Snapshot() Board {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.current()
+}
+
+// copy of the current board, caller hold mu
+func (e *Engine) current() Board {
+	if e.kernel == "bit" && e.pool != nil {
+		return unpack_board(e.bits)
+	}
+	return clone_board(e.board)
+}
+
+func (e *Engine) Generation() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.generation
+}
+
+// stop the workers and wait for them, the engine can not be started again
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	stop, done := e.stop, e.done
+	if stop == nil {
+		// never started, nothing to release
+		e.stop = func() {}
+	}
+	e.mu.Unlock()
+
+	if done != nil {
+		stop()
+		<-done
+	}
+	return nil
+}
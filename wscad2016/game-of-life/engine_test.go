@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wait until the number of goroutines is back to the count before the test
+func check_goroutines(t *testing.T, before int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1 << 16)
+			t.Fatalf("goroutine leak: %d running, %d before\n%s",
+				runtime.NumGoroutine(), before, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_engine_step(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	for _, kernel := range []string{"byte", "bit", "table"} {
+		board := new_random_board(37, 21, 4)
+		want := strings.Join(board_rows(run_parallel(board, 12)), "\n")
+
+		e, err := NewEngine(board, kernel, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Step(1); err == nil {
+			t.Error("Step before Start must fail")
+		}
+		if err := e.Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Start(context.Background()); err == nil {
+			t.Error("second Start must fail")
+		}
+
+		e.Step(5)
+		e.Step(0)
+		e.Step(7)
+		got := e.Snapshot()
+		if strings.Join(board_rows(got), "\n") != want || e.Generation() != 12 {
+			t.Errorf("%s kernel: wrong board at generation %d", kernel, e.Generation())
+		}
+
+		// snapshot is a copy
+		got.data[0][0] = '#'
+		if e.Snapshot().data[0][0] == '#' {
+			t.Errorf("%s kernel: snapshot share the engine board", kernel)
+		}
+
+		e.Close()
+		e.Close()
+		if err := e.Step(1); err == nil {
+			t.Errorf("%s kernel: Step after Close must fail", kernel)
+		}
+	}
+
+	check_goroutines(t, before)
+}
+
+func Test_engine_cancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e, _ := NewEngine(new_random_board(64, 64, 2), "byte", 4)
+	e.Start(ctx)
+
+	result := make(chan error)
+	go func() {
+		result <- e.Step(1 << 30)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-result:
+		if err != context.Canceled {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Step did not return after cancel")
+	}
+	if e.Generation() == 0 {
+		t.Error("no generation before cancel")
+	}
+
+	// workers are released by the context alone, Close is still safe
+	check_goroutines(t, before)
+	e.Close()
+}
+
+func Test_engine_close_unstarted(t *testing.T) {
+	board := new_random_board(4, 4, 1)
+	e, _ := NewEngine(board, "bit", 2)
+	e.Close()
+	if err := e.Start(context.Background()); err == nil {
+		t.Error("Start after Close must fail")
+	}
+	if err := e.Step(1); err == nil {
+		t.Error("Step after Close must fail")
+	}
+	// the bit kernel board is only packed by Start, the input board is still the current one
+	if !same_board(e.Snapshot(), board) {
+		t.Errorf("Snapshot after Close:\n%s", strings.Join(board_rows(e.Snapshot()), "\n"))
+	}
+	if _, err := NewEngine(NewBoard(4, 4), "lut", 2); err == nil {
+		t.Error("expected error for unknown kernel")
+	}
+}
@@ -0,0 +1,87 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"errors"
+)
+
+// Generations rules give a cell more than two states:
+//	0 dead, 1 alive, 2..states-1 dying
+// a live cell that does not survive become dying and count down one state per generation,
+// only live cells are neighbours and a dying cell can not be born.
+//
+// text encoding: ' ' dead, 'x' alive, dying state n is the RLE letter of the state,
+// 'B' for state 2, 'C' for state 3, ...
+
+// Brian's Brain /2/3 has 3 states, letters run out after 'Y' for state 25
+const max_states = 26
+
+// state of a cell byte, unknown bytes are dead
+func cell_state(c byte) int {
+	switch {
+	case c == 'x':
+		return 1
+	case c >= 'B' && c <= 'Y':
+		return int(c - 'A') + 1
+	}
+	return 0
+}
+
+// cell byte of a state
+func state_cell(state int) byte {
+	if state < 2 {
+		return set_life_status(state)
+	}
+	return byte('A' + state - 1)
+}
+
+// state of [row,col], short input rows are dead after their end
+func board_state(b Board, row, col int) int {
+	if col >= len(b.data[row]) {
+		return 0
+	}
+	return cell_state(b.data[row][col])
+}
+
+// next cell of a Generations rule, life is the birth/survive result of the live neighbour count
+func generations_cell(rule Rule, cell byte, life int) byte {
+	state := cell_state(cell)
+	switch {
+	case state == 0:
+		return set_life_status(life)
+	case state == 1 && life == 1:
+		return 'x'
+	case state + 1 < rule.states():
+		// live cell start to die, dying cell count down
+		return state_cell(state + 1)
+	}
+	return ' '
+}
+
+// number of cell states, 2 for a life-like rule
+func (r Rule) states() int {
+	if r.wireworld {
+		return 4
+	}
+	return r.dying + 2
+}
+
+// engines with a two state 3x3 kernel only run life-like rules
+func check_life_like(engine string, rule Rule) error {
+	if rule.wireworld {
+		return errors.New(engine + " does not support WireWorld")
+	}
+	if rule.radius > 0 {
+		return errors.New(engine + " does not support Larger than Life rules")
+	}
+	if rule.dying > 0 {
+		return errors.New(engine + " does not support Generations rules")
+	}
+	if rule.topology != TopologySquare {
+		return errors.New(engine + " does not support the " + rule.topology.String() + " grid")
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Brian's Brain ship, move one row up every generation
+var brain_ship = []string{
+	"        ",
+	"        ",
+	"        ",
+	"        ",
+	"   xx   ",
+	"   BB   ",
+	"        ",
+	"        ",
+}
+
+func Test_parse_generations_rule(t *testing.T) {
+	valid := map[string]string{
+		"/2/3":      "/2/3",
+		"345/2/4":   "345/2/4",
+		"b2/s/c3":   "/2/3",
+		"B3/S23/C2": "B3/S23",
+	}
+	for str, want := range valid {
+		rule, err := parse_rule(str)
+		if err != nil || rule.String() != want {
+			t.Errorf("%s: got %v %v, want %s", str, rule, err, want)
+		}
+	}
+	if rule, _ := parse_rule("345/2/4"); rule.states() != 4 {
+		t.Errorf("Star Wars: got %d states, want 4", rule.states())
+	}
+
+	for _, str := range []string{"/2/1", "/2/27", "/2/x", "/2/", "3/2/4/5", "B2/S/C3/C4"} {
+		if _, err := parse_rule(str); err == nil {
+			t.Errorf("%q: expected error", str)
+		}
+	}
+}
+
+func Test_cell_state(t *testing.T) {
+	for state := 0; state < max_states; state++ {
+		if got := cell_state(state_cell(state)); got != state {
+			t.Errorf("state %d: encoded as %q, read back %d", state, state_cell(state), got)
+		}
+	}
+}
+
+func Test_brians_brain_ship(t *testing.T) {
+	board := new_test_board(t, brain_ship)
+	board.rule, _ = parse_rule("/2/3")
+	board.boundary = BoundaryWrap
+
+	got := board_rows(run_parallel(board, 1))
+	if got[3] != "   xx   " || got[4] != "   BB   " || got[5] != "        " {
+		t.Errorf("step 1:\n%s", strings.Join(got, "\n"))
+	}
+
+	// 8 rows torus bring the ship back in 8 steps
+	if got := board_rows(run_parallel(board, 8)); strings.Join(got, "\n") != strings.Join(brain_ship, "\n") {
+		t.Errorf("step 8:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+// straight forward per cell Generations step as a reference for the row chunk kernel
+func play_generations_reference(b Board) Board {
+	dst := NewBoard(b.width, b.height)
+	dst.boundary, dst.rule = b.boundary, b.rule
+	for r := 0; r < b.height; r++ {
+		for c := 0; c < b.width; c++ {
+			count := 0
+			for dr := -1; dr <= 1; dr++ {
+				for dc := -1; dc <= 1; dc++ {
+					nr, nc := r + dr, c + dc
+					if b.boundary == BoundaryWrap {
+						nr, nc = (nr + b.height) % b.height, (nc + b.width) % b.width
+					}
+					if (dr != 0 || dc != 0) && nr >= 0 && nr < b.height && nc >= 0 && nc < b.width && b.data[nr][nc] == 'x' {
+						count++
+					}
+				}
+			}
+
+			state := cell_state(b.data[r][c])
+			next := 0
+			switch {
+			case state == 0 && b.rule.birth & (1 << uint(count)) != 0:
+				next = 1
+			case state == 1 && b.rule.survive & (1 << uint(count)) != 0:
+				next = 1
+			case state > 0 && state + 1 < b.rule.states():
+				next = state + 1
+			}
+			dst.data[r][c] = state_cell(next)
+		}
+	}
+	return dst
+}
+
+func Test_generations_parallel(t *testing.T) {
+	for _, str := range []string{"/2/3", "345/2/4", "B3/S23/C6", "12/34/9"} {
+		for _, mode := range []Boundary{BoundaryDead, BoundaryWrap} {
+			rule, _ := parse_rule(str)
+			board := new_random_board(23, 17, 11)
+			board.rule, board.boundary = rule, mode
+
+			want := board
+			for i := 0; i < 10; i++ {
+				want = play_generations_reference(want)
+			}
+			got := run_parallel(board, 10)
+			if strings.Join(board_rows(got), "\n") != strings.Join(board_rows(want), "\n") {
+				t.Errorf("%s boundary %d: parallel result differ from reference", str, mode)
+			}
+
+			// merge summary count only live cells and hash every state
+			stats := board_stats(got)
+			if got.stats.population != stats.population || got.stats.hash != stats.hash {
+				t.Errorf("%s boundary %d: merge stats %+v, board stats %+v", str, mode, got.stats, stats)
+			}
+		}
+	}
+}
+
+func Test_generations_cycle(t *testing.T) {
+	board := new_test_board(t, brain_ship)
+	board.rule, _ = parse_rule("/2/3")
+	board.boundary = BoundaryWrap
+	e := new_test_engine(t, board, "byte")
+
+	cycle, err := e.Run(1001, 10)
+	if err != nil || cycle == nil || cycle.period != 8 {
+		t.Fatalf("got %v %v, want period 8", cycle, err)
+	}
+	got := board_rows(e.Snapshot())
+	if got[3] != "   xx   " || got[4] != "   BB   " {
+		t.Errorf("generation 1001:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func Test_generations_life_like_only(t *testing.T) {
+	board := new_test_board(t, brain_ship)
+	board.rule, _ = parse_rule("/2/3")
+
+	if _, err := NewEngine(board, "bit", 4); err == nil {
+		t.Error("bit kernel: expected error")
+	}
+	if _, err := NewHashLife(board); err == nil {
+		t.Error("hashlife: expected error")
+	}
+	if _, err := NewSparseFromBoard(board); err == nil {
+		t.Error("sparse: expected error")
+	}
+	if err := write_board(&bytes.Buffer{}, board, "cells"); err == nil {
+		t.Error("cells output: expected error")
+	}
+}
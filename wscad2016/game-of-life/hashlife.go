@@ -0,0 +1,387 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ------------------ Data type -----------
+
+// Node is a canonical quadtree node of 2^level x 2^level cells, equal sub trees
+// share the same node so a node pointer is its identity
+type Node struct {
+	nw, ne, sw, se *Node
+	level          uint
+	population     int64
+	alive          bool // level 0 leaf only
+}
+
+// highest node level, enough to jump 2^64 generations
+const max_level = 68
+
+// nodes kept in the cache by default, about 200 bytes each with the memoized results
+const default_cache_limit = 1 << 22
+
+type step_key struct {
+	node *Node
+	step uint // node is advanced 2^step generations
+}
+
+// NodeCache canonicalize nodes and memoize the advanced results, safe for
+// concurrent use by the worker goroutines
+type NodeCache struct {
+	mu    sync.Mutex
+	nodes map[[4]*Node]*Node
+	steps map[step_key]*Node
+	empty []*Node // empty node per level
+	leaf  [2]*Node
+	rule  Rule
+
+	// free worker slots, a busy pool compute the sub nodes in the caller
+	workers chan bool
+	size    int64 // number of nodes, for statistics
+
+	// over limit nodes the cache is rebuilt from the universe between two jumps, 0 is no limit
+	limit       int64
+	collections int
+}
+
+func NewNodeCache(rule Rule) *NodeCache {
+	c := &NodeCache{
+		nodes:   make(map[[4]*Node]*Node),
+		steps:   make(map[step_key]*Node),
+		rule:    rule,
+		workers: make(chan bool, runtime.NumCPU()),
+		limit:   default_cache_limit,
+	}
+	c.leaf[0] = &Node{}
+	c.leaf[1] = &Node{alive: true, population: 1}
+
+	// empty nodes are built once, the slice is read only after this
+	c.empty = []*Node{c.leaf[0]}
+	for level := 1; level <= max_level; level++ {
+		e := c.empty[level - 1]
+		c.empty = append(c.empty, c.join(e, e, e, e))
+	}
+	return c
+}
+
+// canonical node of the four quadrants
+func (c *NodeCache) join(nw, ne, sw, se *Node) *Node {
+	key := [4]*Node{nw, ne, sw, se}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n, ok := c.nodes[key]; ok {
+		return n
+	}
+	n := &Node{nw: nw, ne: ne, sw: sw, se: se, level: nw.level + 1,
+		population: nw.population + ne.population + sw.population + se.population}
+	c.nodes[key] = n
+	atomic.AddInt64(&c.size, 1)
+	return n
+}
+
+// drop the nodes and the memoized results that root does not use, the kept nodes are
+// the same pointers so root stay canonical, no advance may run at the same time
+func (c *NodeCache) collect(root *Node) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nodes = make(map[[4]*Node]*Node)
+	c.steps = make(map[step_key]*Node)
+	size := int64(0)
+	var keep func(n *Node)
+	keep = func(n *Node) {
+		key := [4]*Node{n.nw, n.ne, n.sw, n.se}
+		if n.level == 0 || c.nodes[key] != nil {
+			return
+		}
+		keep(n.nw)
+		keep(n.ne)
+		keep(n.sw)
+		keep(n.se)
+		c.nodes[key] = n
+		size++
+	}
+	for _, e := range c.empty {
+		keep(e)
+	}
+	keep(root)
+	atomic.StoreInt64(&c.size, size)
+	c.collections++
+}
+
+func (c *NodeCache) empty_node(level uint) *Node {
+	return c.empty[level]
+}
+
+// same region with one more level, the old node sit in the center
+func (c *NodeCache) expand(n *Node) *Node {
+	e := c.empty_node(n.level - 1)
+	return c.join(
+		c.join(e, e, e, n.nw), c.join(e, e, n.ne, e),
+		c.join(e, n.sw, e, e), c.join(n.se, e, e, e))
+}
+
+// center 2^(level-1) square of the node
+func (c *NodeCache) center(n *Node) *Node {
+	return c.join(n.nw.se, n.ne.sw, n.sw.ne, n.se.nw)
+}
+
+// true when all live cells are inside the center square
+func (c *NodeCache) is_centered(n *Node) bool {
+	return c.center(n).population == n.population
+}
+
+// -------------------- problem solving functions ----------------------
+
+// 4x4 node one generation later, return the 2x2 center
+func (c *NodeCache) slow_step(n *Node) *Node {
+	cell := func(r, col int) int {
+		q := [2][2]*Node{{n.nw, n.ne}, {n.sw, n.se}}[r / 2][col / 2]
+		leaf := [2][2]*Node{{q.nw, q.ne}, {q.sw, q.se}}[r % 2][col % 2]
+		if leaf.alive {
+			return 1
+		}
+		return 0
+	}
+
+	next := func(r, col int) *Node {
+		count := 0
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				if dr != 0 || dc != 0 {
+					count += cell(r + dr, col + dc)
+				}
+			}
+		}
+		return c.leaf[game_of_life_status(c.rule, cell(r, col), count)]
+	}
+
+	return c.join(next(1, 1), next(1, 2), next(2, 1), next(2, 2))
+}
+
+// run the jobs, on a free worker slot in a new goroutine or in the caller
+func (c *NodeCache) parallel(jobs []func()) {
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		select {
+		case c.workers <- true:
+			wg.Add(1)
+			go func(job func()) {
+				defer wg.Done()
+				job()
+				<-c.workers
+			}(job)
+		default:
+			job()
+		}
+	}
+	wg.Wait()
+}
+
+// sub trees from this level are evaluated in parallel, lower levels are too cheap
+const parallel_level = 8
+
+// center of the node after 2^step generations, step <= level - 2
+func (c *NodeCache) advance(n *Node, step uint) *Node {
+	if n.population == 0 {
+		return c.empty_node(n.level - 1)
+	}
+	if n.level == 2 {
+		return c.slow_step(n)
+	}
+
+	key := step_key{n, step}
+	c.mu.Lock()
+	result, ok := c.steps[key]
+	c.mu.Unlock()
+	if ok {
+		return result
+	}
+
+	// nine overlapping sub squares of half size
+	sub := [9]*Node{
+		n.nw, c.join(n.nw.ne, n.ne.nw, n.nw.se, n.ne.sw), n.ne,
+		c.join(n.nw.sw, n.nw.se, n.sw.nw, n.sw.ne), c.center(n), c.join(n.ne.sw, n.ne.se, n.se.nw, n.se.ne),
+		n.sw, c.join(n.sw.ne, n.se.nw, n.sw.se, n.se.sw), n.se,
+	}
+
+	// first half: full speed advance 2^(step-1), otherwise only take the center
+	var mid [9]*Node
+	jobs := make([]func(), 9)
+	for i := range sub {
+		i := i
+		if step == n.level - 2 {
+			jobs[i] = func() { mid[i] = c.advance(sub[i], step - 1) }
+		} else {
+			jobs[i] = func() { mid[i] = c.center(sub[i]) }
+		}
+	}
+	c.run(n, jobs)
+
+	// second half: advance the four quadrants
+	next_step := step
+	if step == n.level - 2 {
+		next_step = step - 1
+	}
+	quad := [4]*Node{
+		c.join(mid[0], mid[1], mid[3], mid[4]), c.join(mid[1], mid[2], mid[4], mid[5]),
+		c.join(mid[3], mid[4], mid[6], mid[7]), c.join(mid[4], mid[5], mid[7], mid[8]),
+	}
+	var out [4]*Node
+	jobs = make([]func(), 4)
+	for i := range quad {
+		i := i
+		jobs[i] = func() { out[i] = c.advance(quad[i], next_step) }
+	}
+	c.run(n, jobs)
+
+	result = c.join(out[0], out[1], out[2], out[3])
+
+	c.mu.Lock()
+	c.steps[key] = result
+	c.mu.Unlock()
+	return result
+}
+
+func (c *NodeCache) run(n *Node, jobs []func()) {
+	if n.level < parallel_level {
+		for _, job := range jobs {
+			job()
+		}
+		return
+	}
+	c.parallel(jobs)
+}
+
+// ------------------ universe -----------
+
+// HashLife universe, root is centered on the origin and cover
+// [-2^(level-1), 2^(level-1)) in both axes
+type HashLife struct {
+	cache      *NodeCache
+	root       *Node
+	generation uint64
+}
+
+// load the board, cell [row,col] of the board is [row,col] of the plane
+func NewHashLife(b Board) (*HashLife, error) {
+	if b.rule.birth & 1 != 0 {
+		return nil, errors.New("HashLife does not support B0 rules")
+	}
+	if b.boundary != BoundaryDead {
+		return nil, errors.New("HashLife simulate the unbounded plane, boundary must be dead")
+	}
+	if err := check_life_like("HashLife", b.rule); err != nil {
+		return nil, err
+	}
+
+	cache := NewNodeCache(b.rule)
+	level := uint(3)
+	for (1 << (level - 1)) < max(b.width, b.height) {
+		level++
+	}
+
+	var build func(level uint, row, col int) *Node
+	build = func(level uint, row, col int) *Node {
+		side := 1 << level
+		if row >= b.height || col >= b.width || row + side <= 0 || col + side <= 0 {
+			return cache.empty_node(level)
+		}
+		if level == 0 {
+			if row >= 0 && col >= 0 && board_alive(b, row, col) {
+				return cache.leaf[1]
+			}
+			return cache.leaf[0]
+		}
+		half := side / 2
+		return cache.join(build(level - 1, row, col), build(level - 1, row, col + half),
+			build(level - 1, row + half, col), build(level - 1, row + half, col + half))
+	}
+
+	half := 1 << (level - 1)
+	return &HashLife{cache: cache, root: build(level, -half, -half)}, nil
+}
+
+// jump 2^k generations at once
+func (h *HashLife) step_pow2(k uint) {
+	c := h.cache
+	for h.root.level < k + 2 || !c.is_centered(h.root) {
+		if h.root.level + 1 >= max_level {
+			panic("HashLife universe is too large")
+		}
+		h.root = c.expand(h.root)
+	}
+	// one more level keep the pattern inside the result after growing 2^k cells
+	h.root = c.advance(c.expand(h.root), k)
+	h.generation += 1 << k
+
+	// a growing pattern fill the cache without end, a single jump can still go over the limit
+	if c.limit > 0 && atomic.LoadInt64(&c.size) > c.limit {
+		c.collect(h.root)
+	}
+}
+
+// advance n generations, biggest power of two jumps first
+func (h *HashLife) step(n uint64) {
+	for k := uint(63); ; k-- {
+		if n & (1 << k) != 0 {
+			h.step_pow2(k)
+		}
+		if k == 0 {
+			break
+		}
+	}
+}
+
+func (h *HashLife) population() int64 {
+	return h.root.population
+}
+
+// cells of the plane inside [0,height) x [0,width) as a board
+func (h *HashLife) board(width, height int, boundary Boundary) Board {
+	size := max(width, height)
+	// window start at the origin, so it is inside the se quadrant of the root,
+	// go down the nw corner while the window still fit in it
+	n := h.root.se
+	for n.level > 0 && (n.level > 62 || (1 << (n.level - 1)) >= size) {
+		n = n.nw
+	}
+
+	b := NewBoard(width, height)
+	b.boundary, b.rule = boundary, h.cache.rule
+	for i := 0; i < height; i++ {
+		for k := 0; k < width; k++ {
+			b.data[i][k] = ' '
+		}
+	}
+
+	var fill func(n *Node, row, col int)
+	fill = func(n *Node, row, col int) {
+		side := 1 << n.level
+		if n.population == 0 || row >= height || col >= width || row + side <= 0 || col + side <= 0 {
+			return
+		}
+		if n.level == 0 {
+			b.data[row][col] = 'x'
+			return
+		}
+		half := side / 2
+		fill(n.nw, row, col)
+		fill(n.ne, row, col + half)
+		fill(n.sw, row + half, col)
+		fill(n.se, row + half, col + half)
+	}
+
+	fill(n, 0, 0)
+	return b
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// random soup in the middle of an empty board, it does not reach the edges in a few steps
+func new_soup_board(size, soup int, seed int64) Board {
+	board := NewBoard(size, size)
+	src := new_random_board(soup, soup, seed)
+	for i := 0; i < size; i++ {
+		copy(board.data[i], strings.Repeat(" ", size))
+	}
+	for i := 0; i < soup; i++ {
+		copy(board.data[(size - soup) / 2 + i][(size - soup) / 2:], src.data[i])
+	}
+	return board
+}
+
+func Test_hashlife_match_rows(t *testing.T) {
+	for _, str := range []string{"B3/S23", "B36/S23", "B3678/S34678"} {
+		for _, step := range []int{1, 2, 7, 20} {
+			board := new_soup_board(64, 16, int64(step))
+			board.rule, _ = parse_rule(str)
+
+			want := run_parallel(board, step)
+
+			h, err := NewHashLife(board)
+			if err != nil {
+				t.Fatal(err)
+			}
+			h.step(uint64(step))
+			got := h.board(board.width, board.height, board.boundary)
+			if strings.Join(board_rows(got), "\n") != strings.Join(board_rows(want), "\n") {
+				t.Errorf("%s step %d: hashlife differ from row engine", str, step)
+			}
+		}
+	}
+}
+
+func Test_hashlife_r_pentomino(t *testing.T) {
+	p, _ := read_cells(strings.NewReader(".OO\nOO.\n.O.\n"))
+	board, _ := place_pattern(p, 3, 3, 0, 0)
+
+	h, err := NewHashLife(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// R-pentomino settle at generation 1103 with 116 cells, six gliders fly away forever
+	h.step(1103)
+	if h.population() != 116 {
+		t.Errorf("generation 1103: population %d, want 116", h.population())
+	}
+	h.step(1000000000 - 1103)
+	if h.population() != 116 || h.generation != 1000000000 {
+		t.Errorf("generation %d: population %d, want 116", h.generation, h.population())
+	}
+}
+
+func Test_hashlife_cache_limit(t *testing.T) {
+	// the gun keep adding gliders, the cache is rebuilt many times on the way
+	p, _ := read_rle(strings.NewReader(gosper_gun_rle))
+	board, _ := place_pattern(p, 36, 9, 0, 0)
+	limited, _ := NewHashLife(board)
+	limited.cache.limit = 5000
+	free, _ := NewHashLife(board)
+	free.cache.limit = 0
+
+	for _, n := range []uint64{1, 30, 977, 4096, 100000} {
+		limited.step(n)
+		free.step(n)
+		if limited.population() != free.population() {
+			t.Fatalf("generation %d: population %d, want %d", free.generation, limited.population(), free.population())
+		}
+		got := strings.Join(board_rows(limited.board(60, 60, BoundaryDead)), "\n")
+		if got != strings.Join(board_rows(free.board(60, 60, BoundaryDead)), "\n") {
+			t.Fatalf("generation %d: limited cache differ", free.generation)
+		}
+	}
+	if limited.cache.collections == 0 || free.cache.collections != 0 {
+		t.Errorf("collections: %d limited, %d free", limited.cache.collections, free.cache.collections)
+	}
+	if limited.cache.size >= free.cache.size {
+		t.Errorf("nodes: %d limited, %d free", limited.cache.size, free.cache.size)
+	}
+}
+
+func Test_hashlife_reject(t *testing.T) {
+	board := NewBoard(4, 4)
+	board.rule, _ = parse_rule("B0/S8")
+	if _, err := NewHashLife(board); err == nil {
+		t.Error("expected error for B0 rule")
+	}
+
+	board.rule, board.boundary = Conway, BoundaryWrap
+	if _, err := NewHashLife(board); err == nil {
+		t.Error("expected error for wrap boundary")
+	}
+}
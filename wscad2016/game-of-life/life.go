@@ -12,43 +12,141 @@ import (
 	"fmt"	
 	"errors"
 	"runtime"
+	"flag"
+	"strings"
+	"context"
+	"os/signal"
+	"math"
+	"strconv"
 )
 
 // ------------------ Data type -----------
+
+// Boundary decide what is outside of the board edges
+type Boundary int
+
+const (
+	// cells beyond the edges are always dead (bounded plane)
+	BoundaryDead Boundary = iota
+	// row 0 neighbours the last row, column 0 neighbours the last column (torus)
+	BoundaryWrap
+)
+
+// Rule is a Life-like rule, bit n is set when n neighbours give birth or survive
+type Rule struct {
+	birth   uint16
+	survive uint16
+	dying   int // dying states of a Generations rule, 0 for a life-like rule
+
+	// Larger than Life neighbourhood, radius 0 use the 3x3 birth and survive sets above
+	radius  int
+	shape   byte // 'M' Moore square or 'N' von Neumann diamond
+	middle  bool // the cell is part of its own neighbourhood
+	survive_range, birth_range [2]int // inclusive count range
+
+	topology Topology // grid of the 3x3 rules, the counts go up to its neighbours
+
+	wireworld bool // four state circuit rule, birth and survive are not used
+}
+
+// B3/S23
+var Conway = Rule{birth: 1<<3, survive: 1<<2 | 1<<3}
+
+// Stats summarize a generation, merge collect it from the row results
+type Stats struct {
+	population int
+	hash       uint64 // order independent sum of the row hashes
+	births     int
+	deaths     int
+	// bounding box of the live cells, min > max for an empty board
+	min_row, min_col int
+	max_row, max_col int
+}
+
+func NewStats() Stats {
+	return Stats{min_row: math.MaxInt, min_col: math.MaxInt, max_row: -1, max_col: -1}
+}
+
+// add the summary of a row, births and deaths are counted against the previous generation
+func (s *Stats) add_row(row_id int, population int, hash uint64, births, deaths, min_col, max_col int) {
+	s.population += population
+	s.hash += row_hash(row_id, hash)
+	s.births += births
+	s.deaths += deaths
+	if population > 0 {
+		s.min_row, s.max_row = min(s.min_row, row_id), max(s.max_row, row_id)
+		s.min_col, s.max_col = min(s.min_col, min_col), max(s.max_col, max_col)
+	}
+}
+
+// summary of a result row, same fields as RowChunk
+type RowSummary struct {
+	population int
+	hash       uint64
+	births     int
+	deaths     int
+	min_col, max_col int
+}
+
 type Board struct {
 	// optimization: work with byte array
 	data [][]byte
-	size int
+	width int
+	height int
+	boundary Boundary
+	rule Rule
+	stats Stats // filled by merge, zero for a board that was not played
+	dirty *Dirty // rows changed by the generation that made the board, nil for a board that was not played
 }
 
-func NewBoard(sz int) Board {
+func NewBoard(width, height int) Board {
 	// Be careful: slice always reference original underlaying array
-	board := Board{data: make([][]byte, sz), size: sz}
-	for i := 0; i < sz; i++ {
-		board.data[i] = make([]byte, sz)
+	board := Board{data: make([][]byte, height), width: width, height: height, rule: Conway}
+	for i := 0; i < height; i++ {
+		board.data[i] = make([]byte, width)
 	}
 	return board
 }
 
+// Chunk is a unit of work of the worker pool, play return the processed chunk
+type Chunk interface {
+	play() Chunk
+}
+
 type RowChunk struct {
 	row_id int    // row_id of current chunk
 	value  []byte // current data set
 	result []byte // result data set
 	top    []byte // above row of row_value
 	bottom []byte // bottom row of row_value
+	boundary Boundary // edge mode of the board
+	rule   Rule   // birth/survival rule
+	population int    // live cells of result
+	hash   uint64 // FNV-1a of result
+	births int    // dead in value, alive in result
+	deaths int    // alive in value, dead in result
+	min_col, max_col int // first and last live column of result, -1 if none
+	columns []uint64 // bit set of the columns to play, nil play the whole row
+	changed []uint64 // bit set of the columns where result differ from value, nil if none
 }
 
-func NewRowChunk(id, size int) RowChunk {
+func NewRowChunk(id, width int) RowChunk {
 	rc := RowChunk{}
 	rc.row_id = id;
-	rc.value = make([]byte, size)
-	rc.result = make([]byte, size)
-	rc.top = make([]byte, size)
-	rc.bottom = make([]byte, size)
+	rc.value = make([]byte, width)
+	rc.result = make([]byte, width)
+	rc.top = make([]byte, width)
+	rc.bottom = make([]byte, width)
 	return rc
 }
 
-func (rc RowChunk)  play() RowChunk {
+func (rc RowChunk)  play() Chunk {
+	if rc.rule.topology != TopologySquare {
+		return rc.play_topology()
+	}
+	if rc.columns != nil {
+		return rc.play_columns()
+	}
 	size := len(rc.value)
 
 	// Optimization: Use dynamic algo for counting life rules in a single loop
@@ -64,6 +162,11 @@ func (rc RowChunk)  play() RowChunk {
 	// temporary hold the last 3 head value
 	queue := [3]int{ 0, 0, head }
 
+	rc.population, rc.births, rc.deaths = 0, 0, 0
+	rc.changed = nil
+	rc.min_col, rc.max_col = -1, -1
+	rc.hash = fnv_offset
+
 	for k, tail := 0, 0; k < size; k++ {
 		// calculate next head
 		if ( k + 1 < size ) {
@@ -85,7 +188,51 @@ func (rc RowChunk)  play() RowChunk {
 
 		neighbour := head - tail - current
 
-		rc.result[k] = set_life_status(game_of_life_status(current, neighbour))
+		if rc.boundary == BoundaryWrap {
+			// torus: first and last column are neighbours of each other
+			if k == 0 {
+				neighbour += count_X(rc.top[size - 1], rc.bottom[size - 1], rc.value[size - 1])
+			}
+			if k == size - 1 {
+				neighbour += count_X(rc.top[0], rc.bottom[0], rc.value[0])
+			}
+		}
+
+		life := game_of_life_status(rc.rule, current, neighbour)
+		state := life
+		if rc.rule.states() > 2 {
+			if rc.rule.wireworld {
+				rc.result[k] = wireworld_cell(rc.value[k], neighbour)
+			} else {
+				rc.result[k] = generations_cell(rc.rule, rc.value[k], life)
+			}
+			if rc.result[k] != rc.value[k] {
+				// dying cells count down without a birth or a death
+				rc.mark_changed(k)
+			}
+			state = cell_state(rc.result[k])
+			life = 0
+			if state == 1 {
+				life = 1
+			}
+		} else {
+			rc.result[k] = set_life_status(life)
+		}
+
+		// summary of the row for cycle detection and statistics, no extra pass over the board
+		rc.population += life
+		rc.hash = (rc.hash ^ uint64(state)) * fnv_prime
+		if life != current {
+			rc.births += life
+			rc.deaths += current
+			rc.mark_changed(k)
+		}
+		if life == 1 {
+			if rc.min_col < 0 {
+				rc.min_col = k
+			}
+			rc.max_col = k
+		}
 	}
 
 	return rc
@@ -114,6 +261,43 @@ func count_X(x, y, z byte) int {
 	return count
 }
 
+// FNV-1a 64 bit
+const (
+	fnv_offset = 14695981039346656037
+	fnv_prime  = 1099511628211
+)
+
+// hash of a row at row_id, board hash is the sum of them so rows can be merged in any order
+func row_hash(row_id int, hash uint64) uint64 {
+	// splitmix64 finalizer
+	z := hash ^ (uint64(row_id) * 0x9E3779B97F4A7C15)
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// stats of a board that did not come from merge, same hash as the row kernel
+func board_stats(b Board) Stats {
+	stats := NewStats()
+	for i := 0; i < b.height; i++ {
+		population, min_col, max_col := 0, -1, -1
+		hash := uint64(fnv_offset)
+		for k := 0; k < b.width; k++ {
+			state := board_state(b, i, k)
+			if state == 1 {
+				population++
+				if min_col < 0 {
+					min_col = k
+				}
+				max_col = k
+			}
+			hash = (hash ^ uint64(state)) * fnv_prime
+		}
+		stats.add_row(i, population, hash, 0, 0, min_col, max_col)
+	}
+	return stats
+}
+
 func set_life_status(state int) byte {
 	life := byte(' ')
 	if state == 1 {
@@ -124,50 +308,156 @@ func set_life_status(state int) byte {
 }
 
 // game of life rules of new life, died or survive
-func game_of_life_status(current_status int, neighbour int) int {
-	// current status of life (0/1), current status remain same if neighbour=2
-	life := current_status
-
-	if neighbour < 2 || neighbour > 3 {
-		// died for suffocation
-		life = 0
-	} else if neighbour == 3 {
-		// add new born in village
-		life = 1
-	}
-	//else if neighbour == 2 {
-	//	// Hasta la vista = see you again in next round
-	//	life := current_status
-	//}
+func game_of_life_status(rule Rule, current_status int, neighbour int) int {
+	// dead cell look at birth set, live cell look at survive set
+	mask := rule.birth
+	if current_status == 1 {
+		mask = rule.survive
+	}
 
-	return life
+	return int(mask >> uint(neighbour)) & 1
 }
 
-func play_parallel(board Board, in chan <- RowChunk, out <-chan RowChunk) Board {
+// parse rule in Bxx/Syy notation, eg: B3/S23, B36/S23, B2/S
+// or a Generations rule in Syy/Bxx/C notation, eg: /2/3 (Brian's Brain), 345/2/4 (Star Wars), B2/S/C3
+// or a Larger than Life rule, eg: R5,C0,M1,S34..58,B34..45,NM (Bosco's Rule)
+// suffix H is the hexagonal grid, eg: B2/S34H, L the triangular grid with counts a b c for 10 11 12, eg: B4/S345L
+func parse_rule(str string) (Rule, error) {
+	if strings.EqualFold(strings.TrimSpace(str), "WireWorld") {
+		return WireWorld, nil
+	}
+	if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(str)), "R") {
+		return parse_ltl_rule(str)
+	}
+
+	rule := Rule{}
+	topology, body := parse_topology_suffix(str)
+	rule.topology = topology
+	parts := strings.Split(strings.ToUpper(body), "/")
+	if len(parts) == 3 && !strings.ContainsAny(body, "BbSsCc") {
+		parts = []string{"S" + parts[0], "B" + parts[1], "C" + parts[2]}
+	}
+	if len(parts) != 2 && len(parts) != 3 {
+		return rule, errors.New("Invalid rule: " + str)
+	}
+
+	seen := ""
+	for _, part := range parts {
+		if len(part) == 0 || strings.Contains(seen, part[:1]) {
+			return rule, errors.New("Invalid rule: " + str)
+		}
+		seen += part[:1]
+
+		if part[0] == 'C' {
+			// number of states, alive and dead included
+			states, err := strconv.Atoi(part[1:])
+			if err != nil || states < 2 || states > max_states {
+				return rule, errors.New("Invalid rule: " + str)
+			}
+			rule.dying = states - 2
+			continue
+		}
+
+		var mask uint16
+		for _, c := range part[1:] {
+			n := parse_count_digit(c)
+			if n < 0 || n > topology.max_neighbours() {
+				return rule, errors.New("Invalid rule: " + str)
+			}
+			mask |= 1 << uint(n)
+		}
+
+		switch part[0] {
+		case 'B':
+			rule.birth = mask
+		case 'S':
+			rule.survive = mask
+		default:
+			return rule, errors.New("Invalid rule: " + str)
+		}
+	}
+
+	return rule, nil
+}
+
+// rule in Bxx/Syy notation, Generations rule in Syy/Bxx/C notation
+func (r Rule) String() string {
+	if r.wireworld {
+		return "WireWorld"
+	}
+	if r.radius > 0 {
+		return ltl_string(r)
+	}
+
+	birth, survive := "", ""
+	for n := 0; n <= r.topology.max_neighbours(); n++ {
+		if r.birth & (1 << uint(n)) != 0 {
+			birth += count_digit(n)
+		}
+		if r.survive & (1 << uint(n)) != 0 {
+			survive += count_digit(n)
+		}
+	}
+	if r.dying > 0 {
+		return fmt.Sprintf("%s/%s/%d%s", survive, birth, r.states(), r.topology.suffix())
+	}
+	return "B" + birth + "/S" + survive + r.topology.suffix()
+}
+
+func play_parallel(board Board, in chan <- Chunk, out <-chan Chunk) Board {
+	if board.rule.radius > 0 {
+		// Larger than Life need a halo of radius rows
+		return play_parallel_ltl(board, in, out)
+	}
+
+	// only cells next to a change can change, rows without them are taken from the board
+	active, columns := active_rows(board)
 
 	// board splitter, split the board row wise
-	go split(board, in)
+	go split(board, active, columns, in)
 
 	// merge process data and wait until all row processed
-	return merge(board.size, out)
+	dst := merge(board, len(active), out)
+	dst.boundary = board.boundary
+	dst.rule = board.rule
+	return dst
 }
 
-func split(b Board, data_in chan <- RowChunk) {
+func split(b Board, rows []int, columns [][]uint64, data_in chan <- Chunk) {
 
-	for i := 0; i < b.size; i++ {
+	// rows are never written after merge, chunks read them without a copy like life_v1,
+	// only short input lines are copied to the full width
+	empty := make([]byte, b.width)
+	row := func(i int) []byte {
+		if len(b.data[i]) == b.width {
+			return b.data[i]
+		}
+		padded := make([]byte, b.width)
+		copy(padded, b.data[i])
+		return padded
+	}
+
+	for n, i := range rows {
 		// working row id
-		chunk := NewRowChunk(i, b.size)
+		chunk := RowChunk{row_id: i, result: make([]byte, b.width), top: empty, bottom: empty}
+		chunk.boundary = b.boundary
+		chunk.rule = b.rule
+		chunk.columns = columns[n]
 
 		// working row value
-		copy(chunk.value, b.data[i])
+		chunk.value = row(i)
 
-		// default top, bottom row is empty array
+		// default top, bottom row is empty array, wrap mode take the opposite edge
 		if chunk.row_id > 0 {
-			copy(chunk.top, b.data[chunk.row_id - 1])
+			chunk.top = row(chunk.row_id - 1)
+		} else if b.boundary == BoundaryWrap {
+			chunk.top = row(b.height - 1)
 		}
 
-		if chunk.row_id + 1 < b.size {
-			copy(chunk.bottom, b.data[chunk.row_id + 1])
+		if chunk.row_id + 1 < b.height {
+			chunk.bottom = row(chunk.row_id + 1)
+		} else if b.boundary == BoundaryWrap {
+			chunk.bottom = row(0)
 		}
 
 		// write chunk data in worker channel queue
@@ -175,71 +465,76 @@ func split(b Board, data_in chan <- RowChunk) {
 	}
 }
 
-func merge(total int, data_out <-chan RowChunk) Board {
-	dst := NewBoard(total)
+// collect n played rows, the rows that were not played are the same as in src
+func merge(src Board, n int, data_out <-chan Chunk) Board {
+	dst := Board{data: make([][]byte, src.height), width: src.width, height: src.height}
+	dst.dirty = NewDirty(src)
 
 	// Don't use range, channel will not close in each timestep
-	for i := 0; i < total; i++ {
+	for i := 0; i < n; i++ {
 		// get processed data from worker channel queue
-		item, ok := <-data_out
+		data, ok := <-data_out
 		if ok {
-			copy(dst.data[item.row_id], item.result)
+			item := data.(RowChunk)
+			// result is allocated for this chunk, no copy needed
+			dst.data[item.row_id] = item.result
+			dst.dirty.changed[item.row_id] = item.changed
+			dst.dirty.summary[item.row_id] = RowSummary{item.population, item.hash, item.births, item.deaths, item.min_col, item.max_col}
+		}
+	}
+
+	dst.stats = NewStats()
+	for i := range dst.data {
+		if dst.data[i] == nil {
+			// rows are never written after merge, the board can share them
+			dst.data[i] = src.data[i]
+			dst.dirty.summary[i] = src.dirty.summary[i]
+			dst.dirty.summary[i].births, dst.dirty.summary[i].deaths = 0, 0
 		}
+		s := dst.dirty.summary[i]
+		dst.stats.add_row(i, s.population, s.hash, s.births, s.deaths, s.min_col, s.max_col)
 	}
 
 	return dst
 }
 
-func init_worker_pool(pool_size int, state <-chan bool, out <-chan RowChunk, in chan <- RowChunk) {
-	for i := 0; i < pool_size; i++ {
-		go func() {
-			for ; ; {
-				select {
-				case signal := <-state:
-				// exit from loop
-					if signal {
-						break
-					}
-				default:
-					data, ok := <-out
-					if ok {
-						in <- data.play()
-					}
-				}
-			}
-		}()
-	}
-}
+// ------------  input, output -----------
 
-func shutdown_workers(pool_size int, state chan bool, out chan RowChunk, in chan RowChunk){
-	for i := 0; i < pool_size; i++ {
-		state <- true
+func parse_boundary(name string) (Boundary, error) {
+	switch name {
+	case "dead":
+		return BoundaryDead, nil
+	case "wrap":
+		return BoundaryWrap, nil
 	}
-	close(out)
-	close(in)
-	close(state)
+	return BoundaryDead, errors.New("Invalid boundary: " + name)
 }
 
-// ------------  input, output -----------
-
 func read_input(rd io.Reader) (Board, int, error) {
 	scanner := bufio.NewScanner(rd)
 	// data structure for input data types
 
-	var size, step int
+	var width, height, step int
 	//var line string
 
 	if scanner.Scan() {
 		line := scanner.Text()
-		_, err := fmt.Sscanf(line, "%d %d", &size, &step)
+		// header is "size steps" for a square board or "width height steps"
+		var err error
+		if len(strings.Fields(line)) == 3 {
+			_, err = fmt.Sscanf(line, "%d %d %d", &width, &height, &step)
+		} else {
+			_, err = fmt.Sscanf(line, "%d %d", &width, &step)
+			height = width
+		}
 		if err != nil {
 			return Board{}, 0, errors.New("Invalid parameter")
 		}
 	}
 
-	board := NewBoard(size)
+	board := NewBoard(width, height)
 
-	for i := 0; i < size; i++ {
+	for i := 0; i < height; i++ {
 		if scanner.Scan() {
 			line := scanner.Text()
 			board.data[i] = []byte(line)
@@ -249,10 +544,17 @@ func read_input(rd io.Reader) (Board, int, error) {
 	return board, step, nil
 }
 
-func print_board(b Board) {
-	for i := 0; i < b.size; i++ {
-		fmt.Println(string(b.data[i]))
+// write the rows at the full width, short input rows are dead after their end
+func print_board(w io.Writer, b Board) error {
+	bw := bufio.NewWriter(w)
+	row := make([]byte, b.width)
+	for i := 0; i < b.height; i++ {
+		for k := range row {
+			row[k] = state_cell(board_state(b, i, k))
+		}
+		fmt.Fprintln(bw, string(row))
 	}
+	return bw.Flush()
 }
 
 func print_rowchunk(rc RowChunk) {
@@ -271,6 +573,52 @@ func print_rowchunk(rc RowChunk) {
 	fmt.Println("______________________________")
 }
 
+// initial board from stdin with its boundary and rule, also return step count of the text header
+func read_start_board(boundary, rule_str, format, size, offset string) (Board, int, error) {
+	mode, err := parse_boundary(boundary)
+	if err != nil {
+		return Board{}, 0, err
+	}
+
+	// hexagonal and triangular rules read the text encoding of their grid
+	var board Board
+	var step int
+	var file_rule string
+	if rule, rerr := parse_rule(rule_str); rerr == nil && rule.topology != TopologySquare {
+		if format != "text" {
+			return Board{}, 0, fmt.Errorf("The %v grid is read from the text format only", rule.topology)
+		}
+		if size != "0" || offset != "0,0" {
+			return Board{}, 0, fmt.Errorf("The %v grid does not support -size and -offset", rule.topology)
+		}
+		board, step, err = read_topology_text(os.Stdin, rule.topology)
+	} else {
+		board, step, file_rule, err = read_board(os.Stdin, format, size, offset)
+	}
+
+	//if err == nil {
+	//	fmt.Println("Inital board")
+	//	print_board(os.Stdout, board)
+	//}
+
+	if err != nil {
+		return Board{}, 0, err
+	}
+	board.boundary = mode
+
+	// rule of the pattern file is used unless -rule is given
+	rule_set := false
+	flag.Visit(func(f *flag.Flag) {
+		rule_set = rule_set || f.Name == "rule"
+	})
+	if !rule_set && file_rule != "" {
+		rule_str = file_rule
+	}
+
+	board.rule, err = parse_rule(rule_str)
+	return board, step, err
+}
+
 // -------------- input/output ----------------
 
 func main() {
@@ -278,37 +626,279 @@ func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 	cpu := runtime.NumCPU();
 
-	board, step, err := read_input(os.Stdin)
+	boundary := flag.String("boundary", "dead", "board edge mode: dead or wrap")
+	rule_str := flag.String("rule", "B3/S23", "life-like rule in Bxx/Syy notation, Generations rule in Syy/Bxx/C notation, Larger than Life rule Rr,Cc,Mm,Sx..y,Bx..y,Nn or WireWorld")
+	format := flag.String("format", "text", "input format: text, rle, cells, mcl (MCell) or wire (Wireworld circuit text)")
+	output := flag.String("output", "text", "output format: text, rle, cells, mcl (MCell) or wire (Wireworld circuit text)")
+	size := flag.String("size", "0", "board size N or WxH the input is placed on, 0 fit the pattern")
+	offset := flag.String("offset", "0,0", "row,col of the input pattern on the board")
+	steps := flag.Int("steps", -1, "number of steps, default from the text input header")
+	kernel := flag.String("kernel", "byte", "step kernel: byte (one cell per byte), bit (64 cells per word) or table (2x2 blocks from a 4x4 lookup table)")
+	engine := flag.String("engine", "rows", "rows (row-parallel worker pool), hashlife (unbounded plane, memoized quadtree) sparse (unbounded plane, active tiles), tiles (persistent tile per worker) or cluster (horizontal bands on -nodes worker processes)")
+	tile := flag.String("tile", "64x64", "tile shape rows x cols of the tiles engine")
+	hashlife_cache := flag.Int("hashlife-cache", default_cache_limit, "hashlife engine: nodes kept before the cache is rebuilt from the universe, 0 is no limit")
+	stats_file := flag.String("stats", "", "rows engine: write statistics of every generation to this file, a -detect jump is one line with the skipped generations")
+	stats_format := flag.String("stats-format", "csv", "statistics format: csv or jsonl")
+	detect := flag.Int("detect", 0, "rows engine: stop at a still state or an oscillator with period up to this window, 0 is off")
+	checkpoint := flag.String("checkpoint", "", "rows engine: save the run to this file every -checkpoint-every generations and on interrupt")
+	checkpoint_every := flag.Int("checkpoint-every", 10000, "generations between two checkpoints")
+	resume := flag.String("resume", "", "rows engine: continue the run of this checkpoint file instead of reading stdin")
+	render := flag.String("render", "", "rows engine: draw the generations to an animated .gif or to PNG files named by a pattern with %d, eg: frames/gen%06d.png, 1D mode: draw the space-time diagram to a .png or .gif")
+	render_every := flag.Int("render-every", 1, "draw every k-th generation")
+	render_scale := flag.Int("render-scale", 4, "pixels per cell side")
+	render_colors := flag.String("render-colors", "ffffff,000000", "dead and alive colours as hex rrggbb")
+	render_view := flag.String("render-view", "", "viewport row,col,WxH of the drawn part of the board, default the whole board")
+	render_delay := flag.Int("render-delay", 10, "GIF frame delay in 100ths of a second")
+	listen := flag.String("listen", ":7070", "cluster engine: address the coordinator wait for the workers on")
+	nodes := flag.Int("nodes", 2, "cluster engine: number of worker processes")
+	join := flag.String("join", "", "run as a cluster worker of the coordinator at this address, stdin is not read")
+	peer := flag.String("peer", "127.0.0.1:0", "cluster worker: address the worker of the band above connect to")
+	census := flag.String("census", "", "write a census of the objects of the final board to this file, - for stderr")
+	census_radius := flag.Int("census-radius", 2, "live cells at most this many rows and columns apart are one object")
+	census_period := flag.Int("census-period", census_period, "generations an object is run to find its period")
+	search := flag.Int("search", 0, "census of this many random 16x16 soups run on the plane until they settle, written to stdout, stdin is not read")
+	search_seed := flag.Int64("search-seed", 1, "seed of the first soup of the search, soup i use seed + i")
+	search_generations := flag.Int("search-generations", 10000, "a soup not settled after this many generations is reported with its seed")
+	search_rare := flag.Int("search-rare", 10, "write the seeds of the objects found in at most this many soups")
+	search_soup := flag.Int64("search-soup", -1, "write the soup of this seed in the -output format, stdin is not read")
+	line := flag.String("line", "", "1D mode: elementary rule W0 to W255 or totalistic rule TcodeRradius, read \"width steps\" and the row from stdin and write the space-time diagram")
+	line_width := flag.Int("line-width", 0, "1D mode: start from one live cell in the middle of a line this wide, stdin is not read")
+	margolus := flag.String("margolus", "", "block automaton mode: 2x2 block rule BBM, Critters, Tron or MS,D followed by the 16 next blocks, play the board read like -format")
+	margolus_back := flag.Bool("margolus-back", false, "block automaton mode: play the steps backward with the inverse of a reversible rule, the input board is an even generation")
+	reverse := flag.Int("reverse", 0, "write a board that turn into the input board after this many generations, found by a SAT solver, 0 is off")
+	reverse_margin := flag.Int("reverse-margin", 0, "predecessor search: dead cells added on every side of the input board")
+	reverse_dimacs := flag.String("reverse-dimacs", "", "predecessor search: write the CNF to this DIMACS file for an external solver instead of solving it")
+	reverse_model := flag.String("reverse-model", "", "predecessor search: decode the output of an external solver for the -reverse-dimacs CNF from this file")
+	flag.Parse()
 
-	//if err == nil {
-	//	fmt.Println("Inital board")
-	//	print_board(board)
-	//}
+	worker_pool_size := cpu*8
+
+	if *join != "" {
+		if err := run_worker(*join, *peer, cpu * 8); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
+	render_opts := NewRenderOptions()
+	render_opts.every, render_opts.scale, render_opts.delay = *render_every, *render_scale, *render_delay
+	var err error
+	render_opts.dead, render_opts.alive, err = parse_colors(*render_colors)
+	if err == nil {
+		render_opts.view, err = parse_viewport(*render_view)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	wstate := make(chan bool)
-	data_in := make(chan RowChunk)
-	data_out := make(chan RowChunk)
-	worker_pool_size := cpu*8
+	if *line != "" {
+		if err := run_line(*line, *line_width, *steps, *boundary, *output, *render, render_opts, worker_pool_size); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *margolus != "" {
+		if err := run_margolus(*margolus, *boundary, *format, *size, *offset, *steps, *margolus_back, *output, worker_pool_size); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *search > 0 || *search_soup >= 0 {
+		rule, err := parse_rule(*rule_str)
+		if err == nil && *search_soup >= 0 {
+			err = write_board(os.Stdout, soup_board(*search_soup, rule), *output)
+		} else if err == nil {
+			opts := NewSearchOptions()
+			opts.radius, opts.max_period, opts.generations, opts.rare, opts.workers = *census_radius, *census_period, *search_generations, *search_rare, cpu
+
+			// interrupt stop the search, the soups already done are reported
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			var s *Search
+			s, err = search_soups(ctx, rule, *search_seed, *search, opts)
+			stop()
+			if s != nil {
+				if werr := s.write(os.Stdout); err == nil {
+					err = werr
+				}
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var board Board
+	var step int
+	var resumed Checkpoint
+	if *resume != "" {
+		// board, boundary, rule and target of the checkpoint, input flags are not used
+		resumed, err = load_checkpoint(*resume)
+		board, step = resumed.board, resumed.target
+	} else {
+		board, step, err = read_start_board(*boundary, *rule_str, *format, *size, *offset)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *steps >= 0 {
+		step = *steps
+	}
+
+	// interrupt stop the simulation between two generations
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	go init_worker_pool(worker_pool_size, wstate, data_in, data_out)
+	if *reverse > 0 {
+		if err := run_reverse(ctx, board, *reverse, *reverse_margin, *reverse_dimacs, *reverse_model, *output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	for i := 0; i < step; i++ {
-		// clone board inside the func and return calculated fresh copy
-		//next := play(board)
-		// concurrent and parrallel processing
-		next := play_parallel(board, data_in, data_out)
-		// reference new board
-		board = next
+	if *engine != "rows" && (*checkpoint != "" || *resume != "" || *render != "") {
+		fmt.Fprintf(os.Stderr, "Error: checkpoint, resume and render need the rows engine\n")
+		os.Exit(1)
+	}
+
+	switch *engine {
+	case "rows":
+		var e *Engine
+		if *resume != "" {
+			e, err = NewEngineFromCheckpoint(resumed, *kernel, worker_pool_size)
+		} else {
+			e, err = NewEngine(board, *kernel, worker_pool_size)
+		}
+		if err == nil && e.Generation() > step {
+			err = fmt.Errorf("checkpoint generation %d is past the last step %d", e.Generation(), step)
+		}
+		if err == nil {
+			e.Start(ctx)
+			var sw *StatsWriter
+			if *stats_file != "" {
+				sw, err = open_stats(e, *stats_file, *stats_format)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			var rd *Renderer
+			if *render != "" {
+				rd, err = open_render(e, *render, render_opts)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			// a resumed run keep saving to its checkpoint file unless -checkpoint is given
+			path := *checkpoint
+			if path == "" {
+				path = *resume
+			}
+			var cp *Checkpointer
+			if path != "" {
+				cp, err = e.CheckpointEvery(path, *checkpoint_every, step)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			if *detect > 0 {
+				var cycle *Cycle
+				if cycle, err = e.Run(step - e.Generation(), *detect); cycle != nil {
+					fmt.Fprintf(os.Stderr, "%v, jump to generation %d\n", cycle, e.Generation())
+				}
+			} else {
+				err = e.Step(step - e.Generation())
+			}
+			if err != nil {
+				if path != "" && e.SaveCheckpoint(path, step) == nil {
+					fmt.Fprintf(os.Stderr, "checkpoint of generation %d saved to %s\n", e.Generation(), path)
+				}
+				err = fmt.Errorf("stopped at generation %d: %v", e.Generation(), err)
+			} else if cp != nil {
+				err = cp.err
+			}
+			board = e.Snapshot()
+			e.Close()
+			if sw != nil {
+				if ferr := sw.close(); ferr != nil && err == nil {
+					err = ferr
+				}
+			}
+			if rd != nil {
+				if rerr := rd.close(); rerr != nil && err == nil {
+					err = rerr
+				}
+			}
+		}
+	case "hashlife":
+		var h *HashLife
+		if h, err = NewHashLife(board); err == nil {
+			h.cache.limit = int64(*hashlife_cache)
+			h.step(uint64(step))
+			board = h.board(board.width, board.height, board.boundary)
+		}
+	case "sparse":
+		var s *Sparse
+		if s, err = NewSparseFromBoard(board); err == nil {
+			pool := NewWorkerPool(worker_pool_size)
+			for i := 0; i < step; i++ {
+				play_parallel_sparse(s, pool.in, pool.out)
+			}
+			pool.close()
+			board = sparse_window(s, board.width, board.height)
+		}
+	case "tiles":
+		var rows, cols int
+		if rows, cols, err = parse_tile(*tile); err == nil {
+			err = check_life_like("Tiles engine", board.rule)
+		}
+		if err == nil {
+			t := NewTiled(board, rows, cols)
+			t.step(step)
+			board = t.board()
+			t.close()
+		}
+	case "cluster":
+		var c *Coordinator
+		if c, err = NewCoordinator(*listen, *nodes); err == nil {
+			fmt.Fprintf(os.Stderr, "waiting for %d workers on %s\n", *nodes, c.Addr())
+			go func() {
+				<-ctx.Done()
+				c.close()
+			}()
+			board, err = c.run(board, step)
+			c.close()
+		}
+	default:
+		err = errors.New("Invalid engine: " + *engine)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	// signal to shutdown worker pool
-	go shutdown_workers(worker_pool_size, wstate, data_in, data_out)
-	
 	// print final board
-	print_board(board)
+	if err := write_board(os.Stdout, board, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *census != "" {
+		if err := write_census(board, *census, *census_radius, *census_period, cpu); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 }
@@ -11,28 +11,42 @@ import (
 	"os"
 	"fmt"
 	"errors"
+	"flag"
+	"strings"
 )
 
 // ------------------ Data type -----------
 
+// Boundary decide what is outside of the board edges
+type Boundary int
+
+const (
+	// cells beyond the edges are always dead (bounded plane)
+	BoundaryDead Boundary = iota
+	// row 0 neighbours the last row, column 0 neighbours the last column (torus)
+	BoundaryWrap
+)
+
 type Board struct {
 	data [][]int
-	size int
+	width int
+	height int
+	boundary Boundary
 }
 
-func NewBoard(sz int) Board {
+func NewBoard(width, height int) Board {
 	// Be careful: slice always reference original underlaying array
-	board := Board{data: make([][]int, sz), size: sz}
-	for i := 0; i < sz; i++ {
-		board.data[i] = make([]int, sz)
+	board := Board{data: make([][]int, height), width: width, height: height}
+	for i := 0; i < height; i++ {
+		board.data[i] = make([]int, width)
 	}
 	return board
 }
 
 func (src Board) clone() Board {
-	dst := Board{data: make([][]int, src.size), size: src.size}
-	for i := 0; i < src.size; i++ {
-		row := make([]int, src.size)
+	dst := Board{data: make([][]int, src.height), width: src.width, height: src.height, boundary: src.boundary}
+	for i := 0; i < src.height; i++ {
+		row := make([]int, src.width)
 		copy(row, src.data[i])  // copy(dest, src) !!!
 		dst.data[i] = row
 	}
@@ -54,8 +68,8 @@ func (b Board) set(row int, col int, value int) Board {
 func play(src Board) Board {
 	dst := src.clone()
 
-	for r := 0; r < src.size; r++ {
-		for c := 0; c < src.size; c++ {
+	for r := 0; r < src.height; r++ {
+		for c := 0; c < src.width; c++ {
 			// count the neighbour
 			count := neighbour(src, r, c)
 			life := game_of_life_status(src.data[r][c], count)
@@ -69,6 +83,9 @@ func play(src Board) Board {
 
 // Count the neighbour of [row,col] player
 func neighbour(b Board, row int, col int) int {
+	if b.boundary == BoundaryWrap {
+		return neighbour_wrap(b, row, col)
+	}
 
 	// init count, row start, row end, col start, col end
 	count, rs, re, cs, cr := 0, row, row, col, col
@@ -76,13 +93,13 @@ func neighbour(b Board, row int, col int) int {
 	if row > 0 {
 		rs = row - 1
 	}
-	if row + 1 < b.size {
+	if row + 1 < b.height {
 		re = row + 1
 	}
 	if col > 0 {
 		cs = col - 1
 	}
-	if col + 1 < b.size {
+	if col + 1 < b.width {
 		cr = col + 1
 	}
 
@@ -97,6 +114,22 @@ func neighbour(b Board, row int, col int) int {
 	return count
 }
 
+// Count the neighbour of [row,col] player on a torus, index outside the board take the opposite edge
+func neighbour_wrap(b Board, row int, col int) int {
+	count := 0
+	for dr := -1; dr <= 1; dr++ {
+		for dc := -1; dc <= 1; dc++ {
+			if dr == 0 && dc == 0 {
+				continue
+			}
+			r := (row + dr + b.height) % b.height
+			c := (col + dc + b.width) % b.width
+			count += b.data[r][c]
+		}
+	}
+	return count
+}
+
 // game of life rules of new life, died or survive
 func game_of_life_status(current_status int, neighbour int) int {
 	// current status of life (0/1), current status remain same if neighbour=2
@@ -119,24 +152,41 @@ func game_of_life_status(current_status int, neighbour int) int {
 
 // ------------  input, output -----------
 
+func parse_boundary(name string) (Boundary, error) {
+	switch name {
+	case "dead":
+		return BoundaryDead, nil
+	case "wrap":
+		return BoundaryWrap, nil
+	}
+	return BoundaryDead, errors.New("Invalid boundary: " + name)
+}
+
 func read_input(rd io.Reader) (Board, int, error) {
 	scanner := bufio.NewScanner(rd)
 	// data structure for input data types
 
-	var size, step int
+	var width, height, step int
 	//var line string
 
 	if scanner.Scan() {
 		line := scanner.Text()
-		_, err := fmt.Sscanf(line, "%d %d", &size, &step)
+		// header is "size steps" for a square board or "width height steps"
+		var err error
+		if len(strings.Fields(line)) == 3 {
+			_, err = fmt.Sscanf(line, "%d %d %d", &width, &height, &step)
+		} else {
+			_, err = fmt.Sscanf(line, "%d %d", &width, &step)
+			height = width
+		}
 		if err != nil {
 			return Board{}, 0, errors.New("Invalid parameter")
 		}
 	}
 
-	board := NewBoard(size)
+	board := NewBoard(width, height)
 
-	for i := 0; i < size; i++ {
+	for i := 0; i < height; i++ {
 		if scanner.Scan() {
 			line := scanner.Text()
 			for k, c := range line {
@@ -153,8 +203,8 @@ func read_input(rd io.Reader) (Board, int, error) {
 }
 
 func print_board(b Board) {
-	for i := 0; i < b.size; i++ {
-		for k := 0; k < b.size; k++ {
+	for i := 0; i < b.height; i++ {
+		for k := 0; k < b.width; k++ {
 			if ( b.data[i][k] == 1 ) {
 				fmt.Print("x")
 			} else {
@@ -169,6 +219,14 @@ func print_board(b Board) {
 // -------------- input/output ----------------
 
 func main() {
+	boundary := flag.String("boundary", "dead", "board edge mode: dead or wrap")
+	flag.Parse()
+
+	mode, err := parse_boundary(*boundary)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	board, step, err := read_input(os.Stdin)
 
@@ -181,6 +239,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	board.boundary = mode
 
 	for i := 0; i < step; i++ {
 		// clone board inside the func and return calculated fresh copy
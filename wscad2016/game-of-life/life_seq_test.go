@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// glider heading to the bottom right corner
+var glider = []string{
+	" x      ",
+	"  x     ",
+	"xxx     ",
+	"        ",
+	"        ",
+	"        ",
+	"        ",
+	"        ",
+}
+
+func new_test_board(t *testing.T, rows []string) Board {
+	input := strings.Join(append([]string{"8 0"}, rows...), "\n")
+	board, _, err := read_input(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return board
+}
+
+func board_rows(b Board) []string {
+	rows := make([]string, b.height)
+	for i := 0; i < b.height; i++ {
+		for k := 0; k < b.width; k++ {
+			if b.data[i][k] == 1 {
+				rows[i] += "x"
+			} else {
+				rows[i] += " "
+			}
+		}
+	}
+	return rows
+}
+
+func Test_glider_wrap(t *testing.T) {
+	board := new_test_board(t, glider)
+	board.boundary = BoundaryWrap
+
+	// glider moves one cell diagonal every 4 steps, 8x8 torus bring it back in 32 steps
+	for i := 0; i < 32; i++ {
+		board = play(board)
+		if i == 23 {
+			// glider is split over the top and bottom edge
+			mid := board_rows(board)
+			if !strings.Contains(mid[0], "x") || !strings.Contains(mid[7], "x") {
+				t.Errorf("glider did not cross the edge:\n%s", strings.Join(mid, "\n"))
+			}
+		}
+	}
+
+	end := board_rows(board)
+	for i := range glider {
+		if end[i] != glider[i] {
+			t.Fatalf("row %d: got %q, want %q", i, end[i], glider[i])
+		}
+	}
+}
+
+func Test_glider_dead(t *testing.T) {
+	board := new_test_board(t, glider)
+
+	// bounded plane: glider crash in the corner and settle as a block
+	for i := 0; i < 32; i++ {
+		board = play(board)
+	}
+	end := board_rows(board)
+	if end[6] != "      xx" || end[7] != "      xx" {
+		t.Errorf("got:\n%s", strings.Join(end, "\n"))
+	}
+}
+
+func Test_glider_wrap_strip(t *testing.T) {
+	board, _, err := read_input(strings.NewReader("12 6 0\n x\n  x\nxxx\n"))
+	if err != nil || board.width != 12 || board.height != 6 {
+		t.Fatalf("header: got %dx%d %v", board.width, board.height, err)
+	}
+	board.boundary = BoundaryWrap
+	start := strings.Join(board_rows(board), "\n")
+
+	// 12x6 torus bring the glider back after 4 * lcm(12, 6) steps, not before
+	for i := 1; i <= 48; i++ {
+		board = play(board)
+		if got := strings.Join(board_rows(board), "\n"); (got == start) != (i == 48) {
+			t.Fatalf("step %d:\n%s", i, got)
+		}
+	}
+}
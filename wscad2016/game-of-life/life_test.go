@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// glider heading to the bottom right corner
+var glider = []string{
+	" x      ",
+	"  x     ",
+	"xxx     ",
+	"        ",
+	"        ",
+	"        ",
+	"        ",
+	"        ",
+}
+
+func new_test_board(t *testing.T, rows []string) Board {
+	input := strings.Join(append([]string{"8 0"}, rows...), "\n")
+	board, _, err := read_input(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return board
+}
+
+func run_parallel(board Board, step int) Board {
+	pool := NewWorkerPool(4)
+	defer pool.close()
+
+	for i := 0; i < step; i++ {
+		board = play_parallel(board, pool.in, pool.out)
+	}
+	return board
+}
+
+// final board of the sequential reference program life_seq.go
+func run_seq(t *testing.T, board Board, step int, args ...string) []string {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found, can not run life_seq.go")
+	}
+
+	input := fmt.Sprintf("%d %d %d\n%s\n", board.width, board.height, step, strings.Join(board_rows(board), "\n"))
+	cmd := exec.Command("go", append([]string{"run", "life_seq.go"}, args...)...)
+	cmd.Stdin = strings.NewReader(input)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal("life_seq.go: ", err)
+	}
+	return strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+}
+
+func board_rows(b Board) []string {
+	rows := make([]string, b.height)
+	for i := 0; i < b.height; i++ {
+		rows[i] = string(b.data[i])
+	}
+	return rows
+}
+
+func Test_glider_wrap(t *testing.T) {
+	board := new_test_board(t, glider)
+	board.boundary = BoundaryWrap
+
+	// after 24 steps the glider moved 6 cells and is split over all four edges
+	mid := board_rows(run_parallel(board, 24))
+	left, right := "", ""
+	for _, row := range mid {
+		left += row[:1]
+		right += row[7:]
+	}
+	if !strings.Contains(mid[0], "x") || !strings.Contains(mid[7], "x") ||
+		!strings.Contains(left, "x") || !strings.Contains(right, "x") {
+		t.Errorf("glider did not cross the edge:\n%s", strings.Join(mid, "\n"))
+	}
+
+	// glider moves one cell diagonal every 4 steps, 8x8 torus bring it back in 32 steps
+	end := board_rows(run_parallel(board, 32))
+	for i := range glider {
+		if end[i] != glider[i] {
+			t.Fatalf("row %d: got %q, want %q", i, end[i], glider[i])
+		}
+	}
+}
+
+func Test_glider_dead(t *testing.T) {
+	board := new_test_board(t, glider)
+
+	// bounded plane: glider crash in the corner and settle as a block
+	end := board_rows(run_parallel(board, 32))
+	want := []string{"      xx", "      xx"}
+	if end[6] != want[0] || end[7] != want[1] {
+		t.Errorf("got:\n%s", strings.Join(end, "\n"))
+	}
+}
+
+func Test_parse_boundary(t *testing.T) {
+	if mode, err := parse_boundary("wrap"); err != nil || mode != BoundaryWrap {
+		t.Error("wrap:", mode, err)
+	}
+	if _, err := parse_boundary("torus"); err == nil {
+		t.Error("expected error for unknown boundary")
+	}
+}
+
+func Test_parse_rule(t *testing.T) {
+	valid := map[string]string{
+		"B3/S23":       "B3/S23",
+		"b36/s23":      "B36/S23",
+		"S34678/B3678": "B3678/S34678",
+		"B2/S":         "B2/S",
+	}
+	for str, want := range valid {
+		rule, err := parse_rule(str)
+		if err != nil || rule.String() != want {
+			t.Errorf("%s: got %v %v, want %s", str, rule, err, want)
+		}
+	}
+	if rule, _ := parse_rule("B3/S23"); rule != Conway {
+		t.Error("B3/S23 is not Conway")
+	}
+
+	for _, str := range []string{"", "B3", "B3/S23/", "B9/S23", "B3/B23", "X3/S23", "B3/S2x"} {
+		if _, err := parse_rule(str); err == nil {
+			t.Errorf("%q: expected error", str)
+		}
+	}
+}
+
+// random width x height board with one third live cells, fixed seed
+func new_random_board(width, height int, seed int64) Board {
+	rnd := rand.New(rand.NewSource(seed))
+	board := NewBoard(width, height)
+	for i := 0; i < height; i++ {
+		for k := 0; k < width; k++ {
+			board.data[i][k] = set_life_status(rnd.Intn(3) / 2)
+		}
+	}
+	return board
+}
+
+// straight forward per cell neighbour count as a reference for the row chunk kernel
+func play_reference(b Board) Board {
+	dst := NewBoard(b.width, b.height)
+	dst.boundary, dst.rule = b.boundary, b.rule
+	for r := 0; r < b.height; r++ {
+		for c := 0; c < b.width; c++ {
+			count := 0
+			for dr := -1; dr <= 1; dr++ {
+				for dc := -1; dc <= 1; dc++ {
+					nr, nc := r + dr, c + dc
+					if b.boundary == BoundaryWrap {
+						nr, nc = (nr + b.height) % b.height, (nc + b.width) % b.width
+					}
+					if (dr != 0 || dc != 0) && nr >= 0 && nr < b.height && nc >= 0 && nc < b.width {
+						count += count_X(b.data[nr][nc], 0, 0)
+					}
+				}
+			}
+			dst.data[r][c] = set_life_status(game_of_life_status(b.rule, count_X(b.data[r][c], 0, 0), count))
+		}
+	}
+	return dst
+}
+
+func Test_rules_parallel(t *testing.T) {
+	for _, str := range []string{"B3/S23", "B36/S23", "B3678/S34678", "B2/S", "B1357/S1357"} {
+		for _, mode := range []Boundary{BoundaryDead, BoundaryWrap} {
+			rule, _ := parse_rule(str)
+			board := new_random_board(23, 17, 7)
+			board.rule, board.boundary = rule, mode
+
+			want := board
+			for i := 0; i < 10; i++ {
+				want = play_reference(want)
+			}
+			got := run_parallel(board, 10)
+			if strings.Join(board_rows(got), "\n") != strings.Join(board_rows(want), "\n") {
+				t.Errorf("%s boundary %d: parallel result differ from reference", str, mode)
+			}
+		}
+	}
+}
+
+func Test_read_input_header(t *testing.T) {
+	board, step, err := read_input(strings.NewReader("5 3 9\n  x\n"))
+	if err != nil || board.width != 5 || board.height != 3 || step != 9 {
+		t.Fatalf("got %dx%d %d %v", board.width, board.height, step, err)
+	}
+	board, step, err = read_input(strings.NewReader("4 2\n"))
+	if err != nil || board.width != 4 || board.height != 4 || step != 2 {
+		t.Fatalf("got %dx%d %d %v", board.width, board.height, step, err)
+	}
+}
+
+func Test_rectangle_match_seq(t *testing.T) {
+	for _, name := range []string{"dead", "wrap"} {
+		// wide strip, width is not a multiple of 64 for the bit kernel
+		board := new_random_board(150, 7, 5)
+		board.boundary, _ = parse_boundary(name)
+		want := strings.Join(run_seq(t, board, 20, "-boundary", name), "\n")
+
+		if got := strings.Join(board_rows(run_parallel(board, 20)), "\n"); got != want {
+			t.Errorf("%s: byte kernel differ from life_seq.go", name)
+		}
+		if got := strings.Join(board_rows(unpack_board(run_parallel_bits(pack_board(board), 20))), "\n"); got != want {
+			t.Errorf("%s: bit kernel differ from life_seq.go", name)
+		}
+	}
+}
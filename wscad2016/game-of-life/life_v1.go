@@ -12,13 +12,27 @@ import (
 	"fmt"
 	"errors"
 	"runtime"
+	"flag"
+	"strings"
 )
 
 // ------------------ Data type -----------
 
+// Boundary decide what is outside of the board edges
+type Boundary int
+
+const (
+	// cells beyond the edges are always dead (bounded plane)
+	BoundaryDead Boundary = iota
+	// row 0 neighbours the last row, column 0 neighbours the last column (torus)
+	BoundaryWrap
+)
+
 type Board struct {
 	data [][]int
-	size int
+	width int
+	height int
+	boundary Boundary
 }
 
 type RowChunk struct {
@@ -27,6 +41,7 @@ type RowChunk struct {
 	result []int // result data set
 	top    []int // above row of row_value
 	bottom []int // bottom row of row_value
+	boundary Boundary // edge mode of the board
 }
 
 func (rc RowChunk)  update() RowChunk {
@@ -39,7 +54,7 @@ func (rc RowChunk)  update() RowChunk {
 	// head - tail = row + 1 - row + 2 =  3x3 grid sum
 	head := 0
 	if (  size > 0 ) {
-		head := rc.top[0] + rc.bottom[0] + rc.value[0]
+		head = rc.top[0] + rc.bottom[0] + rc.value[0]
 	}
 	temp := []int{head}
 
@@ -58,25 +73,35 @@ func (rc RowChunk)  update() RowChunk {
 
 		neighbour := head - tail - rc.value[k]
 
+		if rc.boundary == BoundaryWrap {
+			// torus: first and last column are neighbours of each other
+			if k == 0 {
+				neighbour += rc.top[size - 1] + rc.bottom[size - 1] + rc.value[size - 1]
+			}
+			if k == size - 1 {
+				neighbour += rc.top[0] + rc.bottom[0] + rc.value[0]
+			}
+		}
+
 		rc.result[k] = game_of_life_status(rc.value[k], neighbour);
 	}
 
 	return rc
 }
 
-func NewBoard(sz int) Board {
+func NewBoard(width, height int) Board {
 	// Be careful: slice always reference original underlaying array
-	board := Board{data: make([][]int, sz), size: sz}
-	for i := 0; i < sz; i++ {
-		board.data[i] = make([]int, sz)
+	board := Board{data: make([][]int, height), width: width, height: height}
+	for i := 0; i < height; i++ {
+		board.data[i] = make([]int, width)
 	}
 	return board
 }
 
 func (src Board) clone() Board {
-	dst := Board{data: make([][]int, src.size), size: src.size}
-	for i := 0; i < src.size; i++ {
-		row := make([]int, src.size)
+	dst := Board{data: make([][]int, src.height), width: src.width, height: src.height, boundary: src.boundary}
+	for i := 0; i < src.height; i++ {
+		row := make([]int, src.width)
 		copy(row, src.data[i])  // copy(dest, src) !!!
 		dst.data[i] = row
 	}
@@ -93,19 +118,23 @@ func (b Board) set(row int, col int, value int) Board {
 }
 
 func (b Board) neighbour(row int, col int) int {
+	if b.boundary == BoundaryWrap {
+		return b.neighbour_wrap(row, col)
+	}
+
 	// init count, row start, row end, col start, col end
 	count, rs, re, cs, cr := 0, row, row, col, col
 
 	if row > 0 {
 		rs = row - 1
 	}
-	if row + 1 < b.size {
+	if row + 1 < b.height {
 		re = row + 1
 	}
 	if col > 0 {
 		cs = col - 1
 	}
-	if col + 1 < b.size {
+	if col + 1 < b.width {
 		cr = col + 1
 	}
 
@@ -120,6 +149,22 @@ func (b Board) neighbour(row int, col int) int {
 	return count
 }
 
+// neighbour count on a torus, index outside the board take the opposite edge
+func (b Board) neighbour_wrap(row int, col int) int {
+	count := 0
+	for dr := -1; dr <= 1; dr++ {
+		for dc := -1; dc <= 1; dc++ {
+			if dr == 0 && dc == 0 {
+				continue
+			}
+			r := (row + dr + b.height) % b.height
+			c := (col + dc + b.width) % b.width
+			count += b.data[r][c]
+		}
+	}
+	return count
+}
+
 // -------------------- problem solving functions ----------------------
 
 // Pseudo:
@@ -131,8 +176,8 @@ func (b Board) neighbour(row int, col int) int {
 func play(src Board) Board {
 	dst := src.clone()
 
-	for r := 0; r < src.size; r++ {
-		for c := 0; c < src.size; c++ {
+	for r := 0; r < src.height; r++ {
+		for c := 0; c < src.width; c++ {
 			// count the neighbour
 			count := src.neighbour(r, c)
 			life := game_of_life_status(src.data[r][c], count)
@@ -170,28 +215,35 @@ func play_parallel(board Board, in chan <- RowChunk, out <-chan RowChunk) Board
 	go split(board, in)
 
 	// merge process data and wait until all row processed
-	return merge(board.size, out)
+	dst := merge(board.width, board.height, out)
+	dst.boundary = board.boundary
+	return dst
 }
 
 func split(b Board, data_in chan <- RowChunk) {
 
-	for i := 0; i < b.size; i++ {
+	for i := 0; i < b.height; i++ {
 		chunk := RowChunk{}
 		// working row id
 		chunk.row_id = i
+		chunk.boundary = b.boundary
 		// working row value
 		chunk.value = b.data[i]
 
 		if chunk.row_id > 0 {
 			chunk.top = b.data[chunk.row_id - 1]
+		} else if b.boundary == BoundaryWrap {
+			chunk.top = b.data[b.height - 1]
 		} else {
-			chunk.top = make([]int, b.size)
+			chunk.top = make([]int, b.width)
 		}
 
-		if chunk.row_id + 1 < b.size {
+		if chunk.row_id + 1 < b.height {
 			chunk.bottom = b.data[chunk.row_id + 1]
+		} else if b.boundary == BoundaryWrap {
+			chunk.bottom = b.data[0]
 		} else {
-			chunk.bottom = make([]int, b.size)
+			chunk.bottom = make([]int, b.width)
 		}
 
 		// write chunk data in worker channel queue
@@ -200,11 +252,11 @@ func split(b Board, data_in chan <- RowChunk) {
 
 }
 
-func merge(total int, data_out <-chan RowChunk) Board {
-	dst := NewBoard(total)
+func merge(width, height int, data_out <-chan RowChunk) Board {
+	dst := NewBoard(width, height)
 
 	// Don't use range, channel will not close in each timestep
-	for i := 0; i < total; i++ {
+	for i := 0; i < height; i++ {
 		// get processed data from worker channel queue
 		item, ok := <-data_out
 		if ok {
@@ -236,24 +288,41 @@ func init_worker_pool(pool_size int, state <-chan bool, out <-chan RowChunk, in
 
 // ------------  input, output -----------
 
+func parse_boundary(name string) (Boundary, error) {
+	switch name {
+	case "dead":
+		return BoundaryDead, nil
+	case "wrap":
+		return BoundaryWrap, nil
+	}
+	return BoundaryDead, errors.New("Invalid boundary: " + name)
+}
+
 func read_input(rd io.Reader) (Board, int, error) {
 	scanner := bufio.NewScanner(rd)
 	// data structure for input data types
 
-	var size, step int
+	var width, height, step int
 	//var line string
 
 	if scanner.Scan() {
 		line := scanner.Text()
-		_, err := fmt.Sscanf(line, "%d %d", &size, &step)
+		// header is "size steps" for a square board or "width height steps"
+		var err error
+		if len(strings.Fields(line)) == 3 {
+			_, err = fmt.Sscanf(line, "%d %d %d", &width, &height, &step)
+		} else {
+			_, err = fmt.Sscanf(line, "%d %d", &width, &step)
+			height = width
+		}
 		if err != nil {
 			return Board{}, 0, errors.New("Invalid parameter")
 		}
 	}
 
-	board := NewBoard(size)
+	board := NewBoard(width, height)
 
-	for i := 0; i < size; i++ {
+	for i := 0; i < height; i++ {
 		if scanner.Scan() {
 			line := scanner.Text()
 			for k, c := range line {
@@ -270,8 +339,8 @@ func read_input(rd io.Reader) (Board, int, error) {
 }
 
 func print_board(b Board) {
-	for i := 0; i < b.size; i++ {
-		for k := 0; k < b.size; k++ {
+	for i := 0; i < b.height; i++ {
+		for k := 0; k < b.width; k++ {
 			if ( b.data[i][k] == 1 ) {
 				fmt.Print("x")
 			} else {
@@ -306,6 +375,15 @@ func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 	cpu := runtime.NumCPU();
 
+	boundary := flag.String("boundary", "dead", "board edge mode: dead or wrap")
+	flag.Parse()
+
+	mode, err := parse_boundary(*boundary)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	board, step, err := read_input(os.Stdin)
 
 	//if err == nil {
@@ -317,6 +395,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	board.boundary = mode
 
 	wstate := make(chan bool)
 	data_in := make(chan RowChunk)
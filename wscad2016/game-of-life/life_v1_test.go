@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// glider heading to the bottom right corner
+var glider = []string{
+	" x      ",
+	"  x     ",
+	"xxx     ",
+	"        ",
+	"        ",
+	"        ",
+	"        ",
+	"        ",
+}
+
+func new_test_board(t *testing.T, rows []string) Board {
+	input := strings.Join(append([]string{"8 0"}, rows...), "\n")
+	board, _, err := read_input(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return board
+}
+
+func board_rows(b Board) []string {
+	rows := make([]string, b.height)
+	for i := 0; i < b.height; i++ {
+		for k := 0; k < b.width; k++ {
+			if b.data[i][k] == 1 {
+				rows[i] += "x"
+			} else {
+				rows[i] += " "
+			}
+		}
+	}
+	return rows
+}
+
+func new_test_pool() (chan RowChunk, chan RowChunk) {
+	data_in := make(chan RowChunk)
+	data_out := make(chan RowChunk)
+	go init_worker_pool(4, make(chan bool), data_in, data_out)
+	return data_in, data_out
+}
+
+func Test_glider_wrap(t *testing.T) {
+	board := new_test_board(t, glider)
+	board.boundary = BoundaryWrap
+	data_in, data_out := new_test_pool()
+
+	// glider moves one cell diagonal every 4 steps, 8x8 torus bring it back in 32 steps
+	for i := 0; i < 32; i++ {
+		board = play_parallel(board, data_in, data_out)
+		if i == 23 {
+			// glider is split over the top and bottom edge
+			mid := board_rows(board)
+			if !strings.Contains(mid[0], "x") || !strings.Contains(mid[7], "x") {
+				t.Errorf("glider did not cross the edge:\n%s", strings.Join(mid, "\n"))
+			}
+		}
+	}
+
+	end := board_rows(board)
+	for i := range glider {
+		if end[i] != glider[i] {
+			t.Fatalf("row %d: got %q, want %q", i, end[i], glider[i])
+		}
+	}
+}
+
+func Test_glider_dead(t *testing.T) {
+	board := new_test_board(t, glider)
+
+	data_in, data_out := new_test_pool()
+
+	// bounded plane: glider crash in the corner and settle as a block
+	for i := 0; i < 32; i++ {
+		board = play_parallel(board, data_in, data_out)
+	}
+	end := board_rows(board)
+	if end[6] != "      xx" || end[7] != "      xx" {
+		t.Errorf("got:\n%s", strings.Join(end, "\n"))
+	}
+}
+
+func Test_play_matches_parallel(t *testing.T) {
+	board := new_test_board(t, glider)
+	board.boundary = BoundaryWrap
+	data_in, data_out := new_test_pool()
+
+	// sequential reference of life_v1.go must agree with the row chunk path
+	seq, par := board, board
+	for i := 0; i < 12; i++ {
+		seq = play(seq)
+		par = play_parallel(par, data_in, data_out)
+	}
+	if strings.Join(board_rows(seq), "\n") != strings.Join(board_rows(par), "\n") {
+		t.Errorf("play and play_parallel differ")
+	}
+}
@@ -0,0 +1,258 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image/gif"
+	"io"
+	"math/bits"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ------------------ Data type -----------
+
+// LineRule is a 1D rule of two states, a cell see radius cells on each side. The window of
+// a cell is its 2 * radius + 1 cells as bits, the leftmost cell highest, and the next state
+// is table[window].
+type LineRule struct {
+	radius int
+	table  []byte
+}
+
+// widest neighbourhood of a totalistic rule, the table has 2^(2 * max_line_radius + 1) entries
+const max_line_radius = 7
+
+// cells of a segment played by one worker
+const line_segment = 4096
+
+// Line is a row of cells evolving alone
+type Line struct {
+	cells      []byte // 0 dead, 1 alive
+	rule       LineRule
+	boundary   Boundary
+	generation int
+}
+
+// LineChunk is a segment of the line with radius cells of halo on each side
+type LineChunk struct {
+	start  int // first cell of the segment in the line
+	cells  []byte
+	result []byte
+	rule   *LineRule
+}
+
+// -------------------- problem solving functions ----------------------
+
+// Wolfram elementary rule "W110" or "110", or totalistic rule "T20R2": the bit k of the code
+// is the next state of a cell with k live cells in its window, radius 1 without R
+func parse_line_rule(str string) (LineRule, error) {
+	invalid := errors.New("Invalid 1D rule: " + str)
+	upper := strings.ToUpper(strings.TrimSpace(str))
+	if strings.HasPrefix(upper, "T") {
+		code, radius := upper[1:], "1"
+		if i := strings.Index(code, "R"); i >= 0 {
+			code, radius = code[:i], code[i + 1:]
+		}
+		r, err := strconv.Atoi(radius)
+		if err != nil || r < 1 || r > max_line_radius {
+			return LineRule{}, invalid
+		}
+		// a window of 2r+1 cells has 2r+2 possible counts
+		n, err := strconv.ParseUint(code, 10, 2 * r + 2)
+		if err != nil {
+			return LineRule{}, invalid
+		}
+		rule := LineRule{radius: r, table: make([]byte, 1 << uint(2 * r + 1))}
+		for window := range rule.table {
+			rule.table[window] = byte(n >> uint(bits.OnesCount(uint(window))) & 1)
+		}
+		return rule, nil
+	}
+
+	n, err := strconv.ParseUint(strings.TrimPrefix(upper, "W"), 10, 8)
+	if err != nil {
+		return LineRule{}, invalid
+	}
+	rule := LineRule{radius: 1, table: make([]byte, 8)}
+	for window := range rule.table {
+		rule.table[window] = byte(n >> uint(window) & 1)
+	}
+	return rule, nil
+}
+
+func NewLine(cells []byte, rule LineRule, boundary Boundary) *Line {
+	return &Line{cells: cells, rule: rule, boundary: boundary}
+}
+
+// state of cell k, off the line is dead or wrap around
+func (l *Line) cell(k int) byte {
+	size := len(l.cells)
+	if k < 0 || k >= size {
+		if l.boundary != BoundaryWrap {
+			return 0
+		}
+		k = (k % size + size) % size
+	}
+	return l.cells[k]
+}
+
+func (lc LineChunk) play() Chunk {
+	r := lc.rule.radius
+	mask := 1 << uint(2 * r + 1) - 1
+	lc.result = make([]byte, len(lc.cells) - 2 * r)
+
+	// sliding window, the cell entering on the right is the lowest bit
+	window := 0
+	for k := 0; k < 2 * r; k++ {
+		window = window << 1 | int(lc.cells[k])
+	}
+	for k := range lc.result {
+		window = (window << 1 | int(lc.cells[k + 2 * r])) & mask
+		lc.result[k] = lc.rule.table[window]
+	}
+	return lc
+}
+
+// send the segments of segment cells with their halo, the goroutine only read its own
+// copy of the cells slice and rule so the line can move to the next generation
+func split_line(cells []byte, rule LineRule, boundary Boundary, segment int, data_in chan <- Chunk) {
+	l := Line{cells: cells, rule: rule, boundary: boundary}
+	r := rule.radius
+	for start := 0; start < len(cells); start += segment {
+		end := min(start + segment, len(cells))
+		halo := make([]byte, end - start + 2 * r)
+		for k := range halo {
+			halo[k] = l.cell(start - r + k)
+		}
+		data_in <- LineChunk{start: start, cells: halo, rule: &rule}
+	}
+}
+
+// one generation, segments are played on the worker pool
+func play_parallel_line(l *Line, segment int, data_in chan <- Chunk, data_out <-chan Chunk) {
+	total := (len(l.cells) + segment - 1) / segment
+	go split_line(l.cells, l.rule, l.boundary, segment, data_in)
+
+	next := make([]byte, len(l.cells))
+	for i := 0; i < total; i++ {
+		chunk := (<-data_out).(LineChunk)
+		copy(next[chunk.start:], chunk.result)
+	}
+	l.cells = next
+	l.generation++
+}
+
+// space-time diagram, row g is the line at generation g
+func line_diagram(l *Line, steps, segment int, pool *WorkerPool) Board {
+	b := NewBoard(len(l.cells), steps + 1)
+	for g := 0; g <= steps; g++ {
+		if g > 0 {
+			play_parallel_line(l, segment, pool.in, pool.out)
+		}
+		for k, cell := range l.cells {
+			b.data[g][k] = set_life_status(int(cell))
+		}
+	}
+	return b
+}
+
+// ------------  input, output -----------
+
+// header "width steps" then the row, 'x' is alive, a short row is dead on the right
+func read_line_input(rd io.Reader) ([]byte, int, error) {
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 1 << 20), 1 << 30)
+	var width, step int
+	if !scanner.Scan() {
+		return nil, 0, errors.New("Missing 1D header")
+	}
+	if _, err := fmt.Sscanf(scanner.Text(), "%d %d", &width, &step); err != nil || width < 1 {
+		return nil, 0, errors.New("Invalid 1D header, want width and steps")
+	}
+	cells := make([]byte, width)
+	if scanner.Scan() {
+		for k, c := range []byte(scanner.Text()) {
+			if k < width && c == 'x' {
+				cells[k] = 1
+			}
+		}
+	}
+	return cells, step, scanner.Err()
+}
+
+// one live cell in the middle of a dead line
+func center_line(width int) []byte {
+	cells := make([]byte, width)
+	cells[width / 2] = 1
+	return cells
+}
+
+// diagram as one image, a .gif path is a single frame GIF and other paths a PNG
+func render_diagram(path string, b Board, opts RenderOptions) error {
+	if opts.scale < 1 {
+		return errors.New("Render scale must be positive")
+	}
+	img := draw_cells(crop_board(b, opts.view), opts.scale, state_palette(opts.dead, opts.alive, 2))
+	if !strings.HasSuffix(strings.ToLower(path), ".gif") {
+		return write_png(path, img)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = gif.Encode(file, img, nil)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// 1D mode of the main: read or make the first line, play it and write the diagram
+func run_line(rule_str string, width, steps int, boundary, output, render string, opts RenderOptions, workers int) error {
+	rule, err := parse_line_rule(rule_str)
+	if err != nil {
+		return err
+	}
+	mode, err := parse_boundary(boundary)
+	if err != nil {
+		return err
+	}
+	if output != "text" && output != "cells" {
+		return errors.New("The space-time diagram is written as text or cells")
+	}
+
+	var cells []byte
+	if width > 0 {
+		cells = center_line(width)
+	} else {
+		var step int
+		if cells, step, err = read_line_input(os.Stdin); err != nil {
+			return err
+		}
+		if steps < 0 {
+			steps = step
+		}
+	}
+	if steps < 0 {
+		return errors.New("1D mode need -steps with -line-width")
+	}
+
+	pool := NewWorkerPool(workers)
+	diagram := line_diagram(NewLine(cells, rule, mode), steps, line_segment, pool)
+	pool.close()
+
+	if err := write_board(os.Stdout, diagram, output); err != nil {
+		return err
+	}
+	if render != "" {
+		return render_diagram(render, diagram, opts)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"image/png"
+	"math/bits"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_parse_line_rule(t *testing.T) {
+	// rule 30: 111 110 101 100 011 010 001 000 -> 0 0 0 1 1 1 1 0
+	rule, err := parse_line_rule("W30")
+	if err != nil || rule.radius != 1 || string(rule.table) != "\x00\x01\x01\x01\x01\x00\x00\x00" {
+		t.Errorf("W30: got %v %v", rule, err)
+	}
+	if rule, _ := parse_line_rule("110"); rule.table[6] != 1 || rule.table[7] != 0 {
+		t.Errorf("110: got %v", rule.table)
+	}
+	// T20R2: a cell with 2 or 4 live cells in its window of 5 is alive
+	rule, err = parse_line_rule("t20r2")
+	if err != nil || rule.radius != 2 || len(rule.table) != 32 {
+		t.Fatalf("T20R2: got %v %v", rule, err)
+	}
+	for window, next := range rule.table {
+		count := bits.OnesCount(uint(window))
+		if want := count == 2 || count == 4; (next == 1) != want {
+			t.Errorf("T20R2: window %05b got %d", window, next)
+		}
+	}
+	for _, str := range []string{"W256", "W-1", "T16", "T20R0", "T20R8", "B3/S23", ""} {
+		if _, err := parse_line_rule(str); err == nil {
+			t.Errorf("%q: expected error", str)
+		}
+	}
+}
+
+// next line cell by cell from the rule definitions, independent of the table
+func play_line_reference(cells []byte, rule string, radius int, elementary uint, code uint64, wrap bool) []byte {
+	next := make([]byte, len(cells))
+	for k := range cells {
+		window, count := 0, 0
+		for d := -radius; d <= radius; d++ {
+			i, cell := k + d, byte(0)
+			if wrap {
+				i = (i + len(cells)) % len(cells)
+			}
+			if i >= 0 && i < len(cells) {
+				cell = cells[i]
+			}
+			window = window * 2 + int(cell)
+			count += int(cell)
+		}
+		if strings.HasPrefix(rule, "W") {
+			next[k] = byte(elementary >> uint(window) & 1)
+		} else {
+			next[k] = byte(code >> uint(count) & 1)
+		}
+	}
+	return next
+}
+
+func Test_line_match_reference(t *testing.T) {
+	pool := NewWorkerPool(4)
+	defer pool.close()
+	cases := []struct {
+		rule       string
+		radius     int
+		elementary uint
+		code       uint64
+	}{
+		{"W30", 1, 30, 0},
+		{"W110", 1, 110, 0},
+		{"W90", 1, 90, 0},
+		{"T20R2", 2, 0, 20},
+		{"T1234R5", 5, 0, 1234},
+	}
+	rnd := rand.New(rand.NewSource(5))
+	for _, tc := range cases {
+		rule, _ := parse_line_rule(tc.rule)
+		for _, mode := range []Boundary{BoundaryDead, BoundaryWrap} {
+			for _, segment := range []int{7, 64, line_segment} {
+				cells := make([]byte, 150)
+				for k := range cells {
+					cells[k] = byte(rnd.Intn(2))
+				}
+				want := append([]byte(nil), cells...)
+				diagram := line_diagram(NewLine(cells, rule, mode), 40, segment, pool)
+				for g := 0; g <= 40; g++ {
+					for k, cell := range want {
+						if board_alive(diagram, g, k) != (cell == 1) {
+							t.Fatalf("%s boundary %d segment %d: generation %d differ at %d", tc.rule, mode, segment, g, k)
+						}
+					}
+					want = play_line_reference(want, tc.rule, tc.radius, tc.elementary, tc.code, mode == BoundaryWrap)
+				}
+			}
+		}
+	}
+}
+
+func Test_line_rule30_center(t *testing.T) {
+	// centre column of rule 30 from one cell, OEIS A051023
+	want := "1101110011000101100100111010"
+	pool := NewWorkerPool(2)
+	defer pool.close()
+	rule, _ := parse_line_rule("W30")
+	diagram := line_diagram(NewLine(center_line(101), rule, BoundaryDead), len(want) - 1, 16, pool)
+	got := ""
+	for g := range want {
+		if board_alive(diagram, g, 50) {
+			got += "1"
+		} else {
+			got += "0"
+		}
+	}
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func Test_read_line_input(t *testing.T) {
+	cells, step, err := read_line_input(strings.NewReader("6 9\n x  xxxx\n"))
+	if err != nil || step != 9 || string(cells) != "\x00\x01\x00\x00\x01\x01" {
+		t.Errorf("got %v %d %v", cells, step, err)
+	}
+	if _, _, err := read_line_input(strings.NewReader("x x\n")); err == nil {
+		t.Error("expected error without a header")
+	}
+}
+
+func Test_render_diagram(t *testing.T) {
+	pool := NewWorkerPool(1)
+	defer pool.close()
+	rule, _ := parse_line_rule("W90")
+	diagram := line_diagram(NewLine(center_line(9), rule, BoundaryDead), 4, line_segment, pool)
+
+	path := filepath.Join(t.TempDir(), "w90.png")
+	opts := NewRenderOptions()
+	opts.scale = 3
+	if err := render_diagram(path, diagram, opts); err != nil {
+		t.Fatal(err)
+	}
+	file, _ := os.Open(path)
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil || img.Bounds().Dx() != 27 || img.Bounds().Dy() != 15 {
+		t.Fatalf("got %v %v", img.Bounds(), err)
+	}
+	// the first cell is black, its left neighbour white
+	if r, _, _, _ := img.At(13, 1).RGBA(); r != 0 {
+		t.Error("live cell is not black")
+	}
+	if r, _, _, _ := img.At(10, 1).RGBA(); r == 0 {
+		t.Error("dead cell is black")
+	}
+}
@@ -0,0 +1,190 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// lookup table kernel: the cells are played as 2x2 blocks, the next 2x2 block only depend on
+// the 4x4 cells around it. The 4x4 cells are a 16 bit index, column c of the window is the
+// nibble c and row r the bit r of the nibble:
+//	 0  4  8 12
+//	 1  5  9 13
+//	 2  6 10 14
+//	 3  7 11 15
+// and the table give the next block as bits, 1 and 2 for the top row, 4 and 8 for the bottom.
+// Moving the window two columns right shift the index one byte and add two columns.
+
+// ------------------ Data type -----------
+
+// TableChunk is a pair of rows with the row above and below them
+type TableChunk struct {
+	row_id  int       // first row of the pair
+	played  int       // rows of the pair on the board, 1 for the last row of an odd board
+	rows    [4][]byte // rows row_id - 1 to row_id + 2, nil is outside a dead board
+	result  [2][]byte
+	wrap    bool
+	table   *[1 << 16]byte
+	summary [2]RowSummary
+}
+
+// tables of the rules already played, built on first use
+var life_tables = struct {
+	sync.Mutex
+	tables map[Rule]*[1 << 16]byte
+}{tables: make(map[Rule]*[1 << 16]byte)}
+
+// -------------------- problem solving functions ----------------------
+
+// next 2x2 block of every 4x4 window of a life-like rule
+func life_table(rule Rule) *[1 << 16]byte {
+	life_tables.Lock()
+	defer life_tables.Unlock()
+	if table, ok := life_tables.tables[rule]; ok {
+		return table
+	}
+
+	table := new([1 << 16]byte)
+	for window := range table {
+		cell := func(r, c int) int {
+			return window >> uint(c * 4 + r) & 1
+		}
+		next := byte(0)
+		for bit, center := range [4][2]int{{1, 1}, {1, 2}, {2, 1}, {2, 2}} {
+			count := 0
+			for dr := -1; dr <= 1; dr++ {
+				for dc := -1; dc <= 1; dc++ {
+					if dr != 0 || dc != 0 {
+						count += cell(center[0] + dr, center[1] + dc)
+					}
+				}
+			}
+			next |= byte(game_of_life_status(rule, cell(center[0], center[1]), count) << uint(bit))
+		}
+		table[window] = next
+	}
+	life_tables.tables[rule] = table
+	return table
+}
+
+// 1 for a live cell byte, the columns are built without a branch per cell
+var live_bit = func() (live [256]int) {
+	live['x'] = 1
+	return
+}()
+
+// cell byte of a state
+var life_cell = [2]byte{' ', 'x'}
+
+func (tc TableChunk) play() Chunk {
+	width := len(tc.rows[1])
+
+	// nibble of every column, columns[k + 1] is column k, one column of halo on the left
+	// and two on the right, the last one is only seen by the missing cell of an odd width
+	columns := make([]int, width + 3)
+	for r, row := range tc.rows {
+		for k, cell := range row {
+			columns[k + 1] |= live_bit[cell] << uint(r)
+		}
+	}
+	if tc.wrap {
+		columns[0], columns[width + 1] = columns[width], columns[1]
+	}
+
+	for i := 0; i < tc.played; i++ {
+		tc.result[i] = make([]byte, width)
+		tc.summary[i] = RowSummary{hash: fnv_offset, min_col: -1, max_col: -1}
+	}
+	window := columns[0] | columns[1] << 4
+	for k := 0; k < width; k += 2 {
+		window |= columns[k + 2] << 8 | columns[k + 3] << 12
+		next := int(tc.table[window])
+		// centre of the window in the bit order of the table
+		current := window >> 5 & 1 | window >> 8 & 2 | window >> 4 & 4 | window >> 7 & 8
+
+		// summary of the block like the row kernel, the cells are hashed in column order
+		for i := 0; i < tc.played; i++ {
+			life, was := next >> uint(2 * i) & 3, current >> uint(2 * i) & 3
+			s := &tc.summary[i]
+			tc.result[i][k] = life_cell[life & 1]
+			s.hash = (s.hash ^ uint64(life & 1)) * fnv_prime
+			if k + 1 < width {
+				tc.result[i][k + 1] = life_cell[life >> 1]
+				s.hash = (s.hash ^ uint64(life >> 1)) * fnv_prime
+			} else {
+				life, was = life & 1, was & 1
+			}
+			s.population += bits.OnesCount(uint(life))
+			s.births += bits.OnesCount(uint(life &^ was))
+			s.deaths += bits.OnesCount(uint(was &^ life))
+			if life != 0 {
+				if s.min_col < 0 {
+					s.min_col = k + bits.TrailingZeros(uint(life))
+				}
+				s.max_col = k + bits.Len(uint(life)) - 1
+			}
+		}
+		window >>= 8
+	}
+	return tc
+}
+
+func play_parallel_table(board Board, in chan <- Chunk, out <-chan Chunk) Board {
+
+	// board splitter, split the board in pairs of rows
+	go split_table(board, life_table(board.rule), in)
+
+	// merge process data and wait until all pairs processed
+	dst := merge_table(board, out)
+	dst.boundary = board.boundary
+	dst.rule = board.rule
+	return dst
+}
+
+func split_table(b Board, table *[1 << 16]byte, data_in chan <- Chunk) {
+	wrap := b.boundary == BoundaryWrap
+
+	// short input lines are copied to the full width like split, rows outside a dead board are nil
+	row := func(i int) []byte {
+		if i < 0 || i >= b.height {
+			if !wrap {
+				return nil
+			}
+			i = (i + b.height) % b.height
+		}
+		if len(b.data[i]) == b.width {
+			return b.data[i]
+		}
+		padded := make([]byte, b.width)
+		copy(padded, b.data[i])
+		return padded
+	}
+
+	for i := 0; i < b.height; i += 2 {
+		// the row after the last row of an odd board is only a neighbour
+		chunk := TableChunk{row_id: i, played: min(2, b.height - i), wrap: wrap, table: table}
+		for r := range chunk.rows {
+			chunk.rows[r] = row(i - 1 + r)
+		}
+		data_in <- chunk
+	}
+}
+
+func merge_table(src Board, data_out <-chan Chunk) Board {
+	dst := Board{data: make([][]byte, src.height), width: src.width, height: src.height}
+	dst.stats = NewStats()
+
+	for n := (src.height + 1) / 2; n > 0; n-- {
+		chunk := (<-data_out).(TableChunk)
+		for i := 0; i < chunk.played; i++ {
+			row, s := chunk.row_id + i, chunk.summary[i]
+			dst.data[row] = chunk.result[i]
+			dst.stats.add_row(row, s.population, s.hash, s.births, s.deaths, s.min_col, s.max_col)
+		}
+	}
+	return dst
+}
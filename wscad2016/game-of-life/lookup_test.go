@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_life_table(t *testing.T) {
+	table := life_table(Conway)
+	// vertical blinker in column 1: [1,1] survive with 2, [1,2] is born with 3, [2,1] die with 1
+	cases := map[int]byte{
+		0:                        0,
+		0xffff:                   0,
+		1 << 4 | 1 << 5 | 1 << 6: 1 | 2,
+		1 << 1 | 1 << 5 | 1 << 9: 1 | 4,
+		// block in the centre is a still life
+		1 << 5 | 1 << 6 | 1 << 9 | 1 << 10: 15,
+	}
+	for window, want := range cases {
+		if table[window] != want {
+			t.Errorf("window %016b: got %04b, want %04b", window, table[window], want)
+		}
+	}
+	if life_table(Conway) != table {
+		t.Error("table of the same rule is built again")
+	}
+}
+
+func run_parallel_table(board Board, step int) Board {
+	pool := NewWorkerPool(4)
+	defer pool.close()
+
+	for i := 0; i < step; i++ {
+		board = play_parallel_table(board, pool.in, pool.out)
+	}
+	return board
+}
+
+func Test_table_match_bytes(t *testing.T) {
+	// odd and even sizes, the last block column or row is half on the board
+	for _, size := range []int{1, 2, 3, 5, 64, 65} {
+		for _, str := range []string{"B3/S23", "B36/S23", "B2/S", "B0123478/S01234678"} {
+			for _, mode := range []Boundary{BoundaryDead, BoundaryWrap} {
+				rule, _ := parse_rule(str)
+				board := new_random_board(size, size % 7 + 1, int64(size))
+				board.rule, board.boundary = rule, mode
+
+				want := run_parallel(board, 8)
+				got := run_parallel_table(board, 8)
+				if strings.Join(board_rows(got), "\n") != strings.Join(board_rows(want), "\n") {
+					t.Errorf("size %d %s boundary %d: table kernel differ from byte kernel", size, str, mode)
+				}
+				if got.stats != want.stats {
+					t.Errorf("size %d %s boundary %d: stats %+v, want %+v", size, str, mode, got.stats, want.stats)
+				}
+			}
+		}
+	}
+}
+
+func Test_table_match_seq(t *testing.T) {
+	for _, name := range []string{"dead", "wrap"} {
+		// odd width and height
+		board := new_random_board(75, 13, 8)
+		board.boundary, _ = parse_boundary(name)
+		want := strings.Join(run_seq(t, board, 25, "-boundary", name), "\n")
+
+		if got := strings.Join(board_rows(run_parallel_table(board, 25)), "\n"); got != want {
+			t.Errorf("%s: table kernel differ from life_seq.go", name)
+		}
+	}
+}
+
+func Test_table_life_like_only(t *testing.T) {
+	board := new_random_board(8, 8, 1)
+	board.rule, _ = parse_rule("/2/3")
+	if _, err := NewEngine(board, "table", 4); err == nil {
+		t.Error("expected error for a Generations rule")
+	}
+}
+
+// single thread kernel cost of one generation on a 1024x1024 board, compare with
+// Benchmark_row_chunk_play and Benchmark_bit_row_chunk_play
+
+func Benchmark_table_chunk_play(b *testing.B) {
+	board := new_random_board(1024, 1024, 1)
+	table := life_table(board.rule)
+	chunks := make([]TableChunk, board.height / 2)
+	for i := range chunks {
+		chunks[i] = TableChunk{row_id: i * 2, played: 2, table: table}
+		for r := range chunks[i].rows {
+			if row := i * 2 - 1 + r; row >= 0 && row < board.height {
+				chunks[i].rows[r] = board.data[row]
+			}
+		}
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, tc := range chunks {
+			tc.play()
+		}
+	}
+}
+
+func Benchmark_play_parallel_table(b *testing.B) {
+	board := new_random_board(1024, 1024, 1)
+	pool := NewWorkerPool(8)
+	defer pool.close()
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		play_parallel_table(board, pool.in, pool.out)
+	}
+}
@@ -0,0 +1,279 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ------------------ Data type -----------
+
+// Larger than Life rule in Golly notation, eg Bosco's Rule:
+//	R5,C0,M1,S34..58,B34..45,NM
+// R radius, C states (0 or 2 life-like, more is a Generations rule), M 1 when the cell count itself,
+// S and B survive and birth count range, N neighbourhood shape M (Moore square) or N (von Neumann diamond)
+
+// same limit as Golly
+const max_radius = 500
+
+// rows of a band chunk, the halo above and below is radius rows
+const ltl_band = 16
+
+// LtlChunk is a band of rows, rows hold radius halo rows above and below the band,
+// nil halo row is outside of a dead boundary
+type LtlChunk struct {
+	row_id   int // first row of the band
+	rows     [][]byte
+	result   [][]byte
+	summary  []RowSummary
+	width    int
+	boundary Boundary
+	rule     Rule
+}
+
+// -------------------- problem solving functions ----------------------
+
+// parse Larger than Life rule, R S and B are required
+func parse_ltl_rule(str string) (Rule, error) {
+	rule := Rule{shape: 'M'}
+	invalid := errors.New("Invalid rule: " + str)
+
+	seen := ""
+	for _, part := range strings.Split(strings.ToUpper(strings.TrimSpace(str)), ",") {
+		if len(part) < 2 || strings.Contains(seen, part[:1]) {
+			return Rule{}, invalid
+		}
+		seen += part[:1]
+		value := part[1:]
+
+		switch part[0] {
+		case 'R':
+			radius, err := strconv.Atoi(value)
+			if err != nil || radius < 1 || radius > max_radius {
+				return Rule{}, invalid
+			}
+			rule.radius = radius
+		case 'C':
+			states, err := strconv.Atoi(value)
+			if states == 0 {
+				states = 2
+			}
+			if err != nil || states < 2 || states > max_states {
+				return Rule{}, invalid
+			}
+			rule.dying = states - 2
+		case 'M':
+			if value != "0" && value != "1" {
+				return Rule{}, invalid
+			}
+			rule.middle = value == "1"
+		case 'S', 'B':
+			from, to, ok := strings.Cut(value, "..")
+			lo, err1 := strconv.Atoi(from)
+			hi, err2 := strconv.Atoi(to)
+			if !ok || err1 != nil || err2 != nil || lo < 0 || lo > hi {
+				return Rule{}, invalid
+			}
+			if part[0] == 'S' {
+				rule.survive_range = [2]int{lo, hi}
+			} else {
+				rule.birth_range = [2]int{lo, hi}
+			}
+		case 'N':
+			if value != "M" && value != "N" {
+				return Rule{}, invalid
+			}
+			rule.shape = value[0]
+		default:
+			return Rule{}, invalid
+		}
+	}
+
+	if !strings.Contains(seen, "R") || !strings.Contains(seen, "S") || !strings.Contains(seen, "B") {
+		return Rule{}, invalid
+	}
+	return rule, nil
+}
+
+// Larger than Life rule in Golly notation
+func ltl_string(r Rule) string {
+	states, middle := 0, 0
+	if r.dying > 0 {
+		states = r.states()
+	}
+	if r.middle {
+		middle = 1
+	}
+	return fmt.Sprintf("R%d,C%d,M%d,S%d..%d,B%d..%d,N%c", r.radius, states, middle,
+		r.survive_range[0], r.survive_range[1], r.birth_range[0], r.birth_range[1], r.shape)
+}
+
+// the neighbourhood of a wrapped board must not wrap onto itself, a cell would be counted twice
+func check_ltl(b Board) error {
+	size := 2 * b.rule.radius + 1
+	if b.rule.radius > 0 && b.boundary == BoundaryWrap && (b.width < size || b.height < size) {
+		return fmt.Errorf("Radius %d on a wrapped board need at least %dx%d cells, got %dx%d",
+			b.rule.radius, size, size, b.width, b.height)
+	}
+	return nil
+}
+
+// new life status of a cell with count live cells in its neighbourhood
+func ltl_status(rule Rule, current_status int, count int) int {
+	bounds := rule.birth_range
+	if current_status == 1 {
+		bounds = rule.survive_range
+	}
+	if count >= bounds[0] && count <= bounds[1] {
+		return 1
+	}
+	return 0
+}
+
+func (lc LtlChunk) play() Chunk {
+	r, width := lc.rule.radius, lc.width
+	band := len(lc.rows) - 2 * r
+	padded := width + 2 * r
+
+	// 2D prefix sum of the live cells over the band, halo rows and radius columns on both sides,
+	// sum[i][j] count the cells of rows < i and columns < j
+	sum := make([][]int32, len(lc.rows) + 1)
+	sum[0] = make([]int32, padded + 1)
+	for i, row := range lc.rows {
+		sum[i + 1] = make([]int32, padded + 1)
+		for j := 0; j < padded; j++ {
+			col := j - r
+			if lc.boundary == BoundaryWrap {
+				col = (col % width + width) % width
+			}
+			alive := int32(0)
+			if col >= 0 && col < len(row) && row[col] == 'x' {
+				alive = 1
+			}
+			sum[i + 1][j + 1] = sum[i][j + 1] + sum[i + 1][j] - sum[i][j] + alive
+		}
+	}
+	// live cells of rows r0..r1, columns c0..c1 of the padded band
+	rect := func(r0, c0, r1, c1 int) int {
+		return int(sum[r1 + 1][c1 + 1] - sum[r0][c1 + 1] - sum[r1 + 1][c0] + sum[r0][c0])
+	}
+
+	lc.result = make([][]byte, band)
+	lc.summary = make([]RowSummary, band)
+	for i := 0; i < band; i++ {
+		value := lc.rows[i + r]
+		result := make([]byte, width)
+		s := RowSummary{hash: fnv_offset, min_col: -1, max_col: -1}
+
+		for k := 0; k < width; k++ {
+			// cell is at padded row i + r, column k + r
+			count := 0
+			if lc.rule.shape == 'N' {
+				// diamond is a rectangle of one row per distance
+				for d := -r; d <= r; d++ {
+					h := r - max(d, -d)
+					count += rect(i + r + d, k + r - h, i + r + d, k + r + h)
+				}
+			} else {
+				count = rect(i, k, i + 2 * r, k + 2 * r)
+			}
+
+			cell := byte(' ')
+			if k < len(value) {
+				cell = value[k]
+			}
+			current := 0
+			if cell == 'x' {
+				current = 1
+			}
+			if !lc.rule.middle {
+				count -= current
+			}
+
+			life := ltl_status(lc.rule, current, count)
+			state := life
+			if lc.rule.dying > 0 {
+				result[k] = generations_cell(lc.rule, cell, life)
+				state = cell_state(result[k])
+				if state != 1 {
+					life = 0
+				}
+			} else {
+				result[k] = set_life_status(life)
+			}
+
+			s.population += life
+			s.hash = (s.hash ^ uint64(state)) * fnv_prime
+			if life != current {
+				s.births += life
+				s.deaths += current
+			}
+			if life == 1 {
+				if s.min_col < 0 {
+					s.min_col = k
+				}
+				s.max_col = k
+			}
+		}
+
+		lc.result[i] = result
+		lc.summary[i] = s
+	}
+
+	return lc
+}
+
+func play_parallel_ltl(board Board, in chan <- Chunk, out <-chan Chunk) Board {
+	go split_ltl(board, in)
+
+	dst := merge_ltl(board.width, board.height, out)
+	dst.boundary = board.boundary
+	dst.rule = board.rule
+	return dst
+}
+
+// split the board in bands of ltl_band rows, workers only read the rows so they are not copied
+func split_ltl(b Board, data_in chan <- Chunk) {
+	r := b.rule.radius
+	for row := 0; row < b.height; row += ltl_band {
+		chunk := LtlChunk{row_id: row, width: b.width, boundary: b.boundary, rule: b.rule}
+		end := min(row + ltl_band, b.height)
+
+		chunk.rows = make([][]byte, end - row + 2 * r)
+		for i := range chunk.rows {
+			src := row - r + i
+			if b.boundary == BoundaryWrap {
+				src = (src % b.height + b.height) % b.height
+			}
+			if src >= 0 && src < b.height {
+				chunk.rows[i] = b.data[src]
+			}
+		}
+
+		data_in <- chunk
+	}
+}
+
+func merge_ltl(width, height int, data_out <-chan Chunk) Board {
+	dst := NewBoard(width, height)
+	dst.stats = NewStats()
+
+	for n := (height + ltl_band - 1) / ltl_band; n > 0; n-- {
+		data, ok := <-data_out
+		if ok {
+			item := data.(LtlChunk)
+			for i, s := range item.summary {
+				row_id := item.row_id + i
+				copy(dst.data[row_id], item.result[i])
+				dst.stats.add_row(row_id, s.population, s.hash, s.births, s.deaths, s.min_col, s.max_col)
+			}
+		}
+	}
+
+	return dst
+}
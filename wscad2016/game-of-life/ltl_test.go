@@ -0,0 +1,185 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const bosco = "R5,C0,M1,S34..58,B34..45,NM"
+
+func Test_parse_ltl_rule(t *testing.T) {
+	valid := map[string]string{
+		bosco:                       bosco,
+		"r1,c3,m0,s2..3,b3..3,nn":   "R1,C3,M0,S2..3,B3..3,NN",
+		"R2,S5..9,B7..8":            "R2,C0,M0,S5..9,B7..8,NM",
+		"R10,C2,M1,S123..212,B123..170,NM": "R10,C0,M1,S123..212,B123..170,NM",
+	}
+	for str, want := range valid {
+		rule, err := parse_rule(str)
+		if err != nil || rule.String() != want {
+			t.Errorf("%s: got %v %v, want %s", str, rule, err, want)
+		}
+	}
+
+	for _, str := range []string{"R0,S1..2,B1..2", "R501,S1..2,B1..2", "R5,S34..58", "R5,S58..34,B1..2",
+		"R5,M2,S1..2,B1..2", "R5,NX,S1..2,B1..2", "R5,S1-2,B1..2", "R5,R4,S1..2,B1..2", "R5,C1,S1..2,B1..2"} {
+		if _, err := parse_rule(str); err == nil {
+			t.Errorf("%q: expected error", str)
+		}
+	}
+}
+
+func Test_ltl_radius_one_is_life(t *testing.T) {
+	rule, _ := parse_rule("R1,C0,M0,S2..3,B3..3,NM")
+	for _, mode := range []Boundary{BoundaryDead, BoundaryWrap} {
+		board := new_random_board(41, 35, 3)
+		board.boundary = mode
+		want := run_parallel(board, 10)
+
+		board.rule = rule
+		got := run_parallel(board, 10)
+		if strings.Join(board_rows(got), "\n") != strings.Join(board_rows(want), "\n") {
+			t.Errorf("boundary %d: R1 Moore differ from B3/S23", mode)
+		}
+		if got.stats != want.stats {
+			t.Errorf("boundary %d: stats %+v, want %+v", mode, got.stats, want.stats)
+		}
+	}
+}
+
+// straight forward per cell count of the whole neighbourhood as a reference for the prefix sums
+func play_ltl_reference(b Board) Board {
+	r := b.rule.radius
+	dst := NewBoard(b.width, b.height)
+	dst.boundary, dst.rule = b.boundary, b.rule
+	for row := 0; row < b.height; row++ {
+		for col := 0; col < b.width; col++ {
+			count := 0
+			for dr := -r; dr <= r; dr++ {
+				for dc := -r; dc <= r; dc++ {
+					if b.rule.shape == 'N' && max(dr, -dr) + max(dc, -dc) > r {
+						continue
+					}
+					nr, nc := row + dr, col + dc
+					if b.boundary == BoundaryWrap {
+						nr, nc = (nr % b.height + b.height) % b.height, (nc % b.width + b.width) % b.width
+					}
+					if nr >= 0 && nr < b.height && nc >= 0 && nc < b.width && b.data[nr][nc] == 'x' {
+						count++
+					}
+				}
+			}
+
+			current := 0
+			if b.data[row][col] == 'x' {
+				current = 1
+			}
+			if !b.rule.middle {
+				count -= current
+			}
+			life := ltl_status(b.rule, current, count)
+			dst.data[row][col] = set_life_status(life)
+			if b.rule.dying > 0 {
+				dst.data[row][col] = generations_cell(b.rule, b.data[row][col], life)
+			}
+		}
+	}
+	return dst
+}
+
+func Test_ltl_parallel(t *testing.T) {
+	tests := []struct {
+		rule  string
+		dense bool // Bosco's Rule need a denser soup next to empty space
+	}{
+		{bosco, true},
+		{"R3,C0,M0,S6..10,B5..7,NN", false},
+		{"R2,C4,M1,S4..9,B5..6,NM", false},
+		{"R7,C0,M1,S40..90,B40..60,NN", false},
+	}
+	for _, test := range tests {
+		for _, mode := range []Boundary{BoundaryDead, BoundaryWrap} {
+			str := test.rule
+			rule, _ := parse_rule(str)
+			// 37 rows is three bands, the last one short
+			board := new_random_board(45, 37, 13)
+			if test.dense {
+				more := new_random_board(45, 37, 14)
+				for i := range board.data {
+					for k := range board.data[i] {
+						if k >= 22 {
+							board.data[i][k] = ' '
+						} else if more.data[i][k] == 'x' {
+							board.data[i][k] = 'x'
+						}
+					}
+				}
+			}
+			board.rule, board.boundary = rule, mode
+
+			want := board
+			for i := 0; i < 6; i++ {
+				want = play_ltl_reference(want)
+			}
+			got := run_parallel(board, 6)
+			if strings.Join(board_rows(got), "\n") != strings.Join(board_rows(want), "\n") {
+				t.Errorf("%s boundary %d: parallel result differ from reference", str, mode)
+			}
+
+			stats := board_stats(got)
+			if stats.population == 0 {
+				t.Errorf("%s boundary %d: soup died out, nothing is compared", str, mode)
+			}
+			if got.stats.population != stats.population || got.stats.hash != stats.hash {
+				t.Errorf("%s boundary %d: merge stats %+v, board stats %+v", str, mode, got.stats, stats)
+			}
+		}
+	}
+}
+
+func Test_ltl_life_like_only(t *testing.T) {
+	board := new_random_board(8, 8, 1)
+	board.rule, _ = parse_rule(bosco)
+
+	if _, err := NewEngine(board, "bit", 4); err == nil {
+		t.Error("bit kernel: expected error")
+	}
+	if _, err := NewHashLife(board); err == nil {
+		t.Error("hashlife: expected error")
+	}
+}
+
+func Test_ltl_small_wrapped_board(t *testing.T) {
+	rule, _ := parse_rule("R2,C0,M1,S8..14,B9..12,NM")
+	for _, size := range [][2]int{{4, 8}, {8, 4}, {3, 3}} {
+		board := new_random_board(size[0], size[1], 2)
+		board.rule, board.boundary = rule, BoundaryWrap
+		if _, err := NewEngine(board, "byte", 2); err == nil {
+			t.Errorf("%dx%d: expected error for a window of 5 cells", size[0], size[1])
+		}
+		board.boundary = BoundaryDead
+		if _, err := NewEngine(board, "byte", 2); err != nil {
+			t.Errorf("%dx%d dead boundary: %v", size[0], size[1], err)
+		}
+	}
+
+	// the smallest board, every cell is counted once like the reference
+	board := new_random_board(5, 5, 2)
+	board.rule, board.boundary = rule, BoundaryWrap
+	e := new_test_engine(t, board, "byte")
+	e.Step(3)
+	want := board
+	for i := 0; i < 3; i++ {
+		want = play_ltl_reference(want)
+	}
+	if strings.Join(board_rows(e.Snapshot()), "\n") != strings.Join(board_rows(want), "\n") {
+		t.Error("5x5: engine result differ from reference")
+	}
+}
+
+func Test_ltl_rle_header(t *testing.T) {
+	p, err := read_rle(strings.NewReader("x = 2, y = 1, rule = " + bosco + "\n2o!\n"))
+	if err != nil || p.rule != bosco || p.width != 2 {
+		t.Errorf("got %q %d %v", p.rule, p.width, err)
+	}
+}
@@ -0,0 +1,248 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Margolus block automata update 2x2 blocks instead of single cells, the blocks of even
+// generations start at [0,0] and the blocks of odd generations at [1,1]. The cells of a
+// block are the bits
+//	1 2
+//	4 8
+// and the rule give the next block of each of the 16 blocks. A rule that is a permutation
+// of the blocks is reversible, its inverse play the generations backward.
+//
+// a wrapped board need an even width and height so the blocks tile it, on a dead board the
+// blocks that would cross the edge are left unchanged to keep the rule reversible.
+
+// ------------------ Data type -----------
+
+type BlockRule struct {
+	table [16]byte
+	name  string
+}
+
+// named rules of MCell
+var block_rules = map[string]string{
+	"BBM":      "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15",
+	"CRITTERS": "MS,D15;14;13;3;11;5;6;1;7;9;10;2;12;4;8;0",
+	"TRON":     "MS,D15;1;2;3;4;5;6;7;8;9;10;11;12;13;14;0",
+}
+
+// Margolus is a board played block by block, generation count the steps forward
+type Margolus struct {
+	board      Board
+	rule       BlockRule
+	generation int
+}
+
+// BlockChunk is a row of blocks, the two rows of cells and their row ids
+type BlockChunk struct {
+	row_ids [2]int
+	rows    [2][]byte
+	result  [2][]byte
+	offset  int  // column of the first block
+	wrap    bool // the last block take the first column
+	table   *[16]byte
+}
+
+// -------------------- problem solving functions ----------------------
+
+// rule by name (BBM, Critters, Tron) or in MCell notation "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15"
+func parse_block_rule(str string) (BlockRule, error) {
+	invalid := errors.New("Invalid block rule: " + str)
+	name := strings.TrimSpace(str)
+	if named, ok := block_rules[strings.ToUpper(name)]; ok {
+		str = named
+	}
+	if !strings.HasPrefix(strings.ToUpper(str), "MS,D") {
+		return BlockRule{}, invalid
+	}
+
+	rule := BlockRule{name: name}
+	parts := strings.Split(str[4:], ";")
+	if len(parts) != len(rule.table) {
+		return BlockRule{}, invalid
+	}
+	for block, part := range parts {
+		next, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || next < 0 || next > 15 {
+			return BlockRule{}, invalid
+		}
+		rule.table[block] = byte(next)
+	}
+	return rule, nil
+}
+
+// table of the backward step, error if two blocks have the same next block
+func (r BlockRule) inverse() ([16]byte, error) {
+	var inverse [16]byte
+	seen := 0
+	for block, next := range r.table {
+		if seen & (1 << next) != 0 {
+			return inverse, errors.New("Block rule " + r.name + " is not reversible")
+		}
+		seen |= 1 << next
+		inverse[next] = byte(block)
+	}
+	return inverse, nil
+}
+
+// the rows of the board are copied to the full width, cells other than 'x' are dead
+func NewMargolus(board Board, rule BlockRule) (*Margolus, error) {
+	if board.boundary == BoundaryWrap && (board.width % 2 != 0 || board.height % 2 != 0) {
+		return nil, errors.New("Block automata on a wrapped board need an even width and height")
+	}
+	b := NewBoard(board.width, board.height)
+	b.boundary = board.boundary
+	for i := range b.data {
+		for k := range b.data[i] {
+			b.data[i][k] = set_life_status(0)
+			if board_alive(board, i, k) {
+				b.data[i][k] = set_life_status(1)
+			}
+		}
+	}
+	return &Margolus{board: b, rule: rule}, nil
+}
+
+func block_bit(cell byte, bit byte) byte {
+	if cell == 'x' {
+		return bit
+	}
+	return 0
+}
+
+func (bc BlockChunk) play() Chunk {
+	width := len(bc.rows[0])
+	for i := range bc.rows {
+		bc.result[i] = append([]byte(nil), bc.rows[i]...)
+	}
+	top, bottom := bc.rows[0], bc.rows[1]
+	for left := bc.offset; left < width; left += 2 {
+		right := left + 1
+		if right == width {
+			if !bc.wrap {
+				break
+			}
+			right = 0
+		}
+		block := block_bit(top[left], 1) | block_bit(top[right], 2) | block_bit(bottom[left], 4) | block_bit(bottom[right], 8)
+		next := bc.table[block]
+		bc.result[0][left], bc.result[0][right] = set_life_status(int(next & 1)), set_life_status(int(next >> 1 & 1))
+		bc.result[1][left], bc.result[1][right] = set_life_status(int(next >> 2 & 1)), set_life_status(int(next >> 3 & 1))
+	}
+	return bc
+}
+
+// send the rows of blocks of the phase, a dead board has no block over its last row
+func split_blocks(b Board, phase int, table *[16]byte, data_in chan <- Chunk) {
+	wrap := b.boundary == BoundaryWrap
+	for top := phase; top < b.height; top += 2 {
+		bottom := top + 1
+		if bottom == b.height {
+			if !wrap {
+				break
+			}
+			bottom = 0
+		}
+		data_in <- BlockChunk{row_ids: [2]int{top, bottom}, rows: [2][]byte{b.data[top], b.data[bottom]},
+			offset: phase, wrap: wrap, table: table}
+	}
+}
+
+// number of rows of blocks of the phase
+func block_rows(b Board, phase int) int {
+	if b.boundary == BoundaryWrap {
+		return b.height / 2
+	}
+	return (b.height - phase) / 2
+}
+
+// one generation with the table, the rows of blocks are played on the worker pool,
+// rows outside every block are taken from the board
+func play_parallel_blocks(b Board, phase int, table *[16]byte, data_in chan <- Chunk, data_out <-chan Chunk) Board {
+	go split_blocks(b, phase, table, data_in)
+
+	dst := b
+	dst.data = make([][]byte, b.height)
+	for n := block_rows(b, phase); n > 0; n-- {
+		chunk := (<-data_out).(BlockChunk)
+		for i, row := range chunk.row_ids {
+			dst.data[row] = chunk.result[i]
+		}
+	}
+	for i := range dst.data {
+		if dst.data[i] == nil {
+			dst.data[i] = b.data[i]
+		}
+	}
+	return dst
+}
+
+// play n generations forward
+func (m *Margolus) step(n int, pool *WorkerPool) {
+	for ; n > 0; n-- {
+		m.board = play_parallel_blocks(m.board, m.generation & 1, &m.rule.table, pool.in, pool.out)
+		m.generation++
+	}
+}
+
+// play n generations backward with the inverse table, the phase of each generation is undone
+func (m *Margolus) reverse(n int, pool *WorkerPool) error {
+	inverse, err := m.rule.inverse()
+	if err != nil {
+		return err
+	}
+	for ; n > 0; n-- {
+		m.generation--
+		m.board = play_parallel_blocks(m.board, m.generation & 1, &inverse, pool.in, pool.out)
+	}
+	return nil
+}
+
+// ------------  input, output -----------
+
+// block automaton mode of the main: read the board, play it forward or backward and write it
+func run_margolus(rule_str, boundary, format, size, offset string, steps int, back bool, output string, workers int) error {
+	rule, err := parse_block_rule(rule_str)
+	if err != nil {
+		return err
+	}
+	mode, err := parse_boundary(boundary)
+	if err != nil {
+		return err
+	}
+	board, step, _, err := read_board(os.Stdin, format, size, offset)
+	if err != nil {
+		return err
+	}
+	board.boundary = mode
+	if steps >= 0 {
+		step = steps
+	}
+
+	m, err := NewMargolus(board, rule)
+	if err != nil {
+		return err
+	}
+	pool := NewWorkerPool(workers)
+	if back {
+		err = m.reverse(step, pool)
+	} else {
+		m.step(step, pool)
+	}
+	pool.close()
+	if err != nil {
+		return err
+	}
+
+	return write_board(os.Stdout, m.board, output)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_parse_block_rule(t *testing.T) {
+	rule, err := parse_block_rule(" critters ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// two live cells stay, the other blocks are inverted and three live cells also turned around
+	for block, want := range map[int]byte{0: 15, 3: 3, 9: 9, 1: 14, 7: 1, 14: 8, 15: 0} {
+		if rule.table[block] != want {
+			t.Errorf("Critters block %d: got %d, want %d", block, rule.table[block], want)
+		}
+	}
+	if rule, _ := parse_block_rule("MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15"); rule.table != block_rules_table(t, "BBM") {
+		t.Errorf("MCell notation of BBM: got %v", rule.table)
+	}
+
+	for _, str := range []string{"", "B3/S23", "MS,D0;1;2", "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;16", "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;x"} {
+		if _, err := parse_block_rule(str); err == nil {
+			t.Errorf("%q: expected error", str)
+		}
+	}
+}
+
+func block_rules_table(t *testing.T, name string) [16]byte {
+	rule, err := parse_block_rule(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rule.table
+}
+
+// straight forward per block step as a reference for the parallel rows of blocks
+func play_blocks_reference(b Board, phase int, table [16]byte) Board {
+	dst := NewBoard(b.width, b.height)
+	dst.boundary = b.boundary
+	for i := range b.data {
+		copy(dst.data[i], b.data[i])
+	}
+	for r := phase; r < b.height; r += 2 {
+		for c := phase; c < b.width; c += 2 {
+			rows, cols := []int{r, r + 1}, []int{c, c + 1}
+			if b.boundary == BoundaryWrap {
+				rows[1], cols[1] = rows[1] % b.height, cols[1] % b.width
+			} else if rows[1] == b.height || cols[1] == b.width {
+				continue
+			}
+			block, bit := 0, 1
+			for _, i := range rows {
+				for _, k := range cols {
+					if b.data[i][k] == 'x' {
+						block |= bit
+					}
+					bit <<= 1
+				}
+			}
+			next, bit := int(table[block]), 1
+			for _, i := range rows {
+				for _, k := range cols {
+					dst.data[i][k] = set_life_status(next & bit / bit)
+					bit <<= 1
+				}
+			}
+		}
+	}
+	return dst
+}
+
+func Test_margolus_match_reference(t *testing.T) {
+	pool := NewWorkerPool(4)
+	defer pool.close()
+	for _, name := range []string{"BBM", "Critters", "Tron", "MS,D0;1;1;3;4;5;6;7;8;9;10;11;12;13;14;0"} {
+		for _, size := range [][2]int{{24, 16}, {17, 11}} {
+			for _, mode := range []Boundary{BoundaryDead, BoundaryWrap} {
+				if mode == BoundaryWrap && size[0] % 2 != 0 {
+					continue
+				}
+				board := new_random_board(size[0], size[1], 7)
+				board.boundary = mode
+				rule, _ := parse_block_rule(name)
+				m, err := NewMargolus(board, rule)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				want := m.board
+				for g := 0; g < 12; g++ {
+					want = play_blocks_reference(want, g % 2, rule.table)
+				}
+				m.step(12, pool)
+				if m.generation != 12 || !same_board(m.board, want) {
+					t.Errorf("%s %dx%d boundary %d: parallel result differ from reference", name, size[0], size[1], mode)
+				}
+			}
+		}
+	}
+}
+
+func Test_margolus_forward_backward(t *testing.T) {
+	// a reversible rule played n generations back return the initial board
+	pool := NewWorkerPool(3)
+	defer pool.close()
+	for _, name := range []string{"BBM", "Critters", "Tron"} {
+		for _, mode := range []Boundary{BoundaryDead, BoundaryWrap} {
+			for _, n := range []int{1, 7, 50} {
+				board := new_random_board(32, 20, int64(n))
+				board.boundary = mode
+				rule, _ := parse_block_rule(name)
+				m, _ := NewMargolus(board, rule)
+				start := m.board
+
+				m.step(n, pool)
+				if same_board(m.board, start) {
+					t.Errorf("%s boundary %d: board did not change in %d generations", name, mode, n)
+				}
+				if err := m.reverse(n, pool); err != nil {
+					t.Fatal(err)
+				}
+				if m.generation != 0 || !same_board(m.board, start) {
+					t.Errorf("%s boundary %d: %d generations forward and back differ from the start", name, mode, n)
+				}
+			}
+		}
+	}
+}
+
+func Test_margolus_billiard_ball(t *testing.T) {
+	// a lone ball run diagonally one cell per generation, two balls head on bounce off each other
+	pool := NewWorkerPool(2)
+	defer pool.close()
+	board, _, _ := read_input(strings.NewReader("8 8 0\nx\n"))
+	board.boundary = BoundaryWrap
+	m, _ := NewMargolus(board, BlockRule{table: block_rules_table(t, "BBM")})
+	m.step(5, pool)
+	if !board_alive(m.board, 5, 5) || board_stats(m.board).population != 1 {
+		t.Errorf("generation 5:\n%s", strings.Join(board_rows(m.board), "\n"))
+	}
+
+	board, _, _ = read_input(strings.NewReader("8 8 0\n\n\n  x\n\n\n     x\n"))
+	m, _ = NewMargolus(board, BlockRule{table: block_rules_table(t, "BBM")})
+	m.step(1, pool)
+	if got := board_rows(m.board); got[3] != "   x    " || got[4] != "    x   " {
+		t.Errorf("generation 1:\n%s", strings.Join(got, "\n"))
+	}
+	m.step(1, pool)
+	if got := board_rows(m.board); got[3] != "    x   " || got[4] != "   x    " {
+		t.Errorf("generation 2, balls collide and turn:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func Test_margolus_unsupported(t *testing.T) {
+	rule, _ := parse_block_rule("MS,D0;1;1;3;4;5;6;7;8;9;10;11;12;13;14;15")
+	m, _ := NewMargolus(new_random_board(6, 6, 1), rule)
+	pool := NewWorkerPool(1)
+	defer pool.close()
+	if err := m.reverse(1, pool); err == nil {
+		t.Error("expected error for a rule that is not reversible")
+	}
+
+	board := new_random_board(7, 6, 1)
+	board.boundary = BoundaryWrap
+	if _, err := NewMargolus(board, rule); err == nil {
+		t.Error("expected error for an odd width on a wrapped board")
+	}
+}
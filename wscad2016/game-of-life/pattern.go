@@ -0,0 +1,606 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ------------------ Data type -----------
+
+// Pattern is a rectangular block of cells loaded from a pattern file, cell is 'x' or ' ',
+// or the state letter of a multi-state rule
+type Pattern struct {
+	width  int
+	height int
+	cells  [][]byte
+	rule   string // rule from the file header, empty if not given
+	name   string
+}
+
+func NewPattern(width, height int) Pattern {
+	p := Pattern{width: width, height: height, cells: make([][]byte, height)}
+	for i := 0; i < height; i++ {
+		p.cells[i] = []byte(strings.Repeat(" ", width))
+	}
+	return p
+}
+
+// place the pattern with its top left corner at [row,col] of an empty width x height board,
+// width or height 0 make the board just large enough to hold it
+func place_pattern(p Pattern, width, height, row, col int) (Board, error) {
+	if row < 0 || col < 0 {
+		return Board{}, errors.New("Invalid offset")
+	}
+	if width == 0 {
+		width = col + p.width
+	}
+	if height == 0 {
+		height = row + p.height
+	}
+	if row + p.height > height || col + p.width > width {
+		return Board{}, fmt.Errorf("Pattern %dx%d at %d,%d does not fit in board %dx%d", p.width, p.height, row, col, width, height)
+	}
+
+	board := NewBoard(width, height)
+	for i := 0; i < height; i++ {
+		copy(board.data[i], strings.Repeat(" ", width))
+	}
+	for i := 0; i < p.height; i++ {
+		copy(board.data[row + i][col:], p.cells[i])
+	}
+	return board, nil
+}
+
+// cell value of the board, short input rows are dead after their end
+func board_alive(b Board, row, col int) bool {
+	return col < len(b.data[row]) && b.data[row][col] == 'x'
+}
+
+// ------------  RLE (run length encoded) -----------
+
+// read pattern in Golly/LifeWiki RLE format:
+//	#N name
+//	x = 3, y = 3, rule = B3/S23
+//	bo$2bo$3o!
+func read_rle(rd io.Reader) (Pattern, error) {
+	scanner := bufio.NewScanner(rd)
+
+	p := Pattern{}
+	header := false
+	body := ""
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+
+		if line[0] == '#' {
+			if strings.HasPrefix(line, "#N") {
+				p.name = strings.TrimSpace(line[2:])
+			}
+			continue
+		}
+
+		if !header {
+			if err := parse_rle_header(line, &p); err != nil {
+				return Pattern{}, err
+			}
+			header = true
+			continue
+		}
+
+		body += line
+		if strings.Contains(line, "!") {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Pattern{}, err
+	}
+	if !header {
+		return Pattern{}, errors.New("Invalid RLE: missing header")
+	}
+
+	// header only give the size, allocate the cells now
+	cells := NewPattern(p.width, p.height)
+	cells.name, cells.rule = p.name, p.rule
+	p = cells
+
+	// states of the header rule, B3/S23 when it has none
+	states := Conway.states()
+	if rule, err := parse_rule(p.rule); err == nil && p.rule != "" {
+		states = rule.states()
+	}
+
+	// multi-state cells are 'A' to 'X' for the states 1 to 24, the states above have a
+	// prefix 'p' to 'y' adding 24 each
+	row, col, count, prefix := 0, 0, 0, 0
+	for _, c := range body {
+		switch {
+		case c >= '0' && c <= '9':
+			count = count * 10 + int(c - '0')
+			continue
+		case c >= 'p' && c <= 'y':
+			prefix = int(c - 'o') * 24
+			continue
+		case c == ' ' || c == '\t':
+			continue
+		case c == '!':
+			return p, nil
+		}
+
+		n := count
+		if n == 0 {
+			n = 1
+		}
+		count = 0
+
+		state := prefix
+		prefix = 0
+		switch {
+		case c == '$':
+			row, col = row + n, 0
+			continue
+		case c == 'b' || c == '.':
+			col += n
+			continue
+		case c == 'o':
+			state++
+		case c >= 'A' && c <= 'X':
+			state += int(c - 'A') + 1
+		default:
+			return Pattern{}, fmt.Errorf("Invalid RLE: unexpected %q at row %d", c, row)
+		}
+
+		if state >= states {
+			return Pattern{}, fmt.Errorf("Invalid RLE: state %d at row %d, rule %q has %d states", state, row, p.rule, states)
+		}
+		if row >= p.height || col + n > p.width {
+			return Pattern{}, fmt.Errorf("Invalid RLE: cell outside %dx%d at row %d", p.width, p.height, row)
+		}
+		for ; n > 0; n-- {
+			p.cells[row][col] = state_cell(state)
+			col++
+		}
+	}
+
+	return p, nil
+}
+
+// header line: x = m, y = n[, rule = B3/S23]
+func parse_rle_header(line string, p *Pattern) error {
+	fields := strings.Split(line, ",")
+	for i, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return errors.New("Invalid RLE header: " + line)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+
+		var err error
+		switch key {
+		case "x":
+			p.width, err = strconv.Atoi(value)
+		case "y":
+			p.height, err = strconv.Atoi(value)
+		case "rule":
+			// rule is the last field, a Larger than Life rule contains commas
+			p.rule = strings.TrimSpace(strings.Join(append([]string{value}, fields[i + 1:]...), ","))
+			return nil
+		}
+		if err != nil || p.width < 0 || p.height < 0 {
+			return errors.New("Invalid RLE header: " + line)
+		}
+	}
+	return nil
+}
+
+// tag of a state, 'b' and 'o' for two states, '.' and the letters of read_rle for more
+func rle_tag(state, states int) string {
+	switch {
+	case states == 2:
+		return string("bo"[state])
+	case state == 0:
+		return "."
+	case state > 24:
+		return string([]byte{byte('o' + (state - 1) / 24), byte('A' + (state - 1) % 24)})
+	}
+	return string(byte('A' + state - 1))
+}
+
+// write the board as RLE, lines are kept under 70 characters, multi-state rules with letters
+func write_rle(w io.Writer, b Board) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "x = %d, y = %d, rule = %v\n", b.width, b.height, b.rule)
+
+	line := ""
+	emit := func(n int, tag string) {
+		run := tag
+		if n > 1 {
+			run = strconv.Itoa(n) + run
+		}
+		if len(line) + len(run) > 70 {
+			fmt.Fprintln(bw, line)
+			line = ""
+		}
+		line += run
+	}
+
+	// bytes of no state of the rule are dead like in the kernel
+	states := b.rule.states()
+	state := func(row, col int) int {
+		if s := board_state(b, row, col); s < states {
+			return s
+		}
+		return 0
+	}
+
+	// pending line ends, blank rows are merged into a single n$
+	eol := 0
+	for i := 0; i < b.height; i++ {
+		// trailing dead cells of a row are not written
+		end := b.width
+		for end > 0 && state(i, end - 1) == 0 {
+			end--
+		}
+		if end == 0 {
+			eol++
+			continue
+		}
+		if eol > 0 {
+			emit(eol, "$")
+		}
+		eol = 1
+
+		for k := 0; k < end; {
+			s := state(i, k)
+			n := 1
+			for k + n < end && state(i, k + n) == s {
+				n++
+			}
+			emit(n, rle_tag(s, states))
+			k += n
+		}
+	}
+	line += "!"
+	fmt.Fprintln(bw, line)
+
+	return bw.Flush()
+}
+
+// ------------  plaintext (.cells) -----------
+
+// read pattern in plaintext format, '!' comment lines, '.' dead and 'O' alive
+func read_cells(rd io.Reader) (Pattern, error) {
+	scanner := bufio.NewScanner(rd)
+
+	name := ""
+	lines := []string{}
+	width := 0
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if strings.HasPrefix(line, "!") {
+			if strings.HasPrefix(line, "!Name:") {
+				name = strings.TrimSpace(line[6:])
+			}
+			continue
+		}
+		if len(line) > width {
+			width = len(line)
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return Pattern{}, err
+	}
+
+	p := NewPattern(width, len(lines))
+	p.name = name
+	for i, line := range lines {
+		for k, c := range line {
+			switch c {
+			case 'O', '*':
+				p.cells[i][k] = 'x'
+			case '.':
+			default:
+				return Pattern{}, fmt.Errorf("Invalid cells: unexpected %q in line %d", c, i + 1)
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// write the board in plaintext format
+func write_cells(w io.Writer, b Board) error {
+	bw := bufio.NewWriter(w)
+	row := make([]byte, b.width)
+	for i := 0; i < b.height; i++ {
+		for k := 0; k < b.width; k++ {
+			row[k] = '.'
+			if board_alive(b, i, k) {
+				row[k] = 'O'
+			}
+		}
+		fmt.Fprintln(bw, string(row))
+	}
+	return bw.Flush()
+}
+
+// ------------  MCell (.mcl) -----------
+
+// read pattern in Mirek's Cellebration format:
+//	#MCell 4.20
+//	#GAME Special rules
+//	#RULE WireWorld
+//	#L 3C$C.A.B$3C
+// #L lines run together, '.' is state 0, 'A' to 'X' states 1 to 24 and a count repeat the
+// next cell or '$', Life and Generations games give the rule in S/B[/C] notation
+func read_mcl(rd io.Reader) (Pattern, error) {
+	scanner := bufio.NewScanner(rd)
+
+	game, rule, name, body := "Life", "", "", ""
+	header := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#MCell"):
+			header = true
+		case strings.HasPrefix(line, "#GAME"):
+			game = strings.TrimSpace(line[5:])
+		case strings.HasPrefix(line, "#RULE"):
+			rule = strings.TrimSpace(line[5:])
+		case strings.HasPrefix(line, "#D") && name == "":
+			name = strings.TrimSpace(line[2:])
+		case strings.HasPrefix(line, "#L"):
+			body += strings.TrimSpace(line[2:])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Pattern{}, err
+	}
+	if !header {
+		return Pattern{}, errors.New("Invalid MCell: missing #MCell header")
+	}
+
+	switch game {
+	case "Life":
+		// S/B like the Generations notation, parse_rule want the letters for two parts
+		parts := strings.Split(rule, "/")
+		if len(parts) != 2 {
+			return Pattern{}, errors.New("Invalid MCell rule: " + rule)
+		}
+		rule = "S" + parts[0] + "/B" + parts[1]
+	case "Generations", "Special rules":
+	default:
+		return Pattern{}, errors.New("Unsupported MCell game: " + game)
+	}
+
+	rows := [][]byte{nil}
+	width, count := 0, 0
+	for _, c := range []byte(body) {
+		if c >= '0' && c <= '9' {
+			count = count * 10 + int(c - '0')
+			continue
+		}
+		n := max(count, 1)
+		count = 0
+
+		switch {
+		case c == '$':
+			for ; n > 0; n-- {
+				rows = append(rows, nil)
+			}
+		case c == '.' || c >= 'A' && c <= 'X':
+			cell := byte(' ')
+			if c != '.' {
+				cell = state_cell(int(c - 'A') + 1)
+			}
+			last := len(rows) - 1
+			rows[last] = append(rows[last], bytes.Repeat([]byte{cell}, n)...)
+			width = max(width, len(rows[last]))
+		default:
+			return Pattern{}, fmt.Errorf("Invalid MCell: unexpected %q", c)
+		}
+	}
+
+	p := NewPattern(width, len(rows))
+	p.rule, p.name = rule, name
+	for i, row := range rows {
+		copy(p.cells[i], row)
+	}
+	return p, nil
+}
+
+// MCell game and rule of a 3x3 rule of the square grid
+func mcl_rule(r Rule) (string, string, error) {
+	if r.radius > 0 || r.topology != TopologySquare {
+		return "", "", errors.New("MCell output does not support the rule " + r.String())
+	}
+	switch {
+	case r.wireworld:
+		return "Special rules", r.String(), nil
+	case r.dying > 0:
+		return "Generations", r.String(), nil
+	}
+	// B3/S23 is 23/3
+	parts := strings.Split(strings.TrimPrefix(r.String(), "B"), "/S")
+	return "Life", parts[1] + "/" + parts[0], nil
+}
+
+// write the board in MCell format, #L lines are kept under 70 characters
+func write_mcl(w io.Writer, b Board) error {
+	game, rule, err := mcl_rule(b.rule)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "#MCell 4.20\n#GAME %s\n#RULE %s\n#BOARD %dx%d\n", game, rule, b.width, b.height)
+	wrap := 0
+	if b.boundary == BoundaryWrap {
+		wrap = 1
+	}
+	fmt.Fprintf(bw, "#WRAP %d\n", wrap)
+
+	line := ""
+	emit := func(n int, tag byte) {
+		run := string(tag)
+		if n > 1 {
+			run = strconv.Itoa(n) + run
+		}
+		if len(line) + len(run) > 70 {
+			fmt.Fprintln(bw, "#L " + line)
+			line = ""
+		}
+		line += run
+	}
+
+	// pending row ends, blank rows are merged into a single n$
+	eol := 0
+	for i := 0; i < b.height; i++ {
+		// trailing empty cells of a row are not written
+		end := b.width
+		for end > 0 && board_state(b, i, end - 1) == 0 {
+			end--
+		}
+		if end == 0 {
+			eol++
+			continue
+		}
+		if eol > 0 {
+			emit(eol, '$')
+		}
+		eol = 1
+
+		for k := 0; k < end; {
+			state := board_state(b, i, k)
+			n := 1
+			for k + n < end && board_state(b, i, k + n) == state {
+				n++
+			}
+			tag := byte('.')
+			if state > 0 {
+				tag = byte('A' + state - 1)
+			}
+			emit(n, tag)
+			k += n
+		}
+	}
+	if line != "" {
+		fmt.Fprintln(bw, "#L " + line)
+	}
+
+	return bw.Flush()
+}
+
+// ------------  format selection -----------
+
+// read the board in given format, pattern formats are placed at offset "row,col"
+// of a board with size "N" or "WxH", also return step count of text header and rule of the file
+func read_board(rd io.Reader, format string, size string, offset string) (Board, int, string, error) {
+	var p Pattern
+	var err error
+	step := 0
+	switch format {
+	case "text":
+		var board Board
+		board, step, err = read_input(rd)
+		if err != nil || (size == "0" && offset == "0,0") {
+			return board, step, "", err
+		}
+		// the board of the header is placed like a pattern
+		p = board_pattern(board)
+	case "rle":
+		p, err = read_rle(rd)
+	case "cells":
+		p, err = read_cells(rd)
+	case "mcl":
+		p, err = read_mcl(rd)
+	case "wire":
+		p, err = read_wire(rd)
+	default:
+		return Board{}, 0, "", errors.New("Invalid format: " + format)
+	}
+	if err != nil {
+		return Board{}, 0, "", err
+	}
+
+	var row, col int
+	if _, err := fmt.Sscanf(offset, "%d,%d", &row, &col); err != nil {
+		return Board{}, 0, "", errors.New("Invalid offset: " + offset)
+	}
+
+	width, height, err := parse_size(size)
+	if err != nil {
+		return Board{}, 0, "", err
+	}
+
+	board, err := place_pattern(p, width, height, row, col)
+	return board, step, p.rule, err
+}
+
+// pattern of the whole board, short input rows are dead after their end
+func board_pattern(b Board) Pattern {
+	p := NewPattern(b.width, b.height)
+	for i := range p.cells {
+		for k := range p.cells[i] {
+			p.cells[i][k] = state_cell(board_state(b, i, k))
+		}
+	}
+	return p
+}
+
+// board size "N" for a square board or "WxH"
+func parse_size(str string) (int, int, error) {
+	var width, height int
+	if strings.Contains(str, "x") {
+		_, err := fmt.Sscanf(str, "%dx%d", &width, &height)
+		if err != nil || width < 0 || height < 0 {
+			return 0, 0, errors.New("Invalid size: " + str)
+		}
+		return width, height, nil
+	}
+	_, err := fmt.Sscanf(str, "%d", &width)
+	if err != nil || width < 0 {
+		return 0, 0, errors.New("Invalid size: " + str)
+	}
+	return width, width, nil
+}
+
+// write the board in given format
+func write_board(w io.Writer, b Board, format string) error {
+	switch format {
+	case "text":
+		if b.rule.topology != TopologySquare {
+			return write_topology_text(w, b)
+		}
+		return print_board(w, b)
+	case "rle":
+		// Generations and Wireworld states are written as letters like in Golly
+		rule := b.rule
+		rule.dying, rule.wireworld = 0, false
+		if err := check_life_like("RLE output", rule); err != nil {
+			return err
+		}
+		return write_rle(w, b)
+	case "cells":
+		if err := check_life_like("Plaintext output", b.rule); err != nil {
+			return err
+		}
+		return write_cells(w, b)
+	case "mcl":
+		return write_mcl(w, b)
+	case "wire":
+		return write_wire(w, b)
+	}
+	return errors.New("Invalid format: " + format)
+}
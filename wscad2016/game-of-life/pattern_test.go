@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const gosper_gun_rle = `#N Gosper glider gun
+#C comment line is ignored
+x = 36, y = 9, rule = B3/S23
+24bo$22bobo$12b2o6b2o12b2o$11bo3bo4b2o12b2o$2o8bo5bo3b2o$2o8bo3bob2o4b
+obo$10bo5bo7bo$11bo3bo$12b2o!
+`
+
+func Test_read_rle(t *testing.T) {
+	p, err := read_rle(strings.NewReader(gosper_gun_rle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.width != 36 || p.height != 9 || p.rule != "B3/S23" || p.name != "Gosper glider gun" {
+		t.Fatalf("header: %dx%d %q %q", p.width, p.height, p.rule, p.name)
+	}
+
+	live := 0
+	for _, row := range p.cells {
+		live += strings.Count(string(row), "x")
+	}
+	if live != 36 {
+		t.Errorf("live cells: got %d, want 36", live)
+	}
+	if string(p.cells[4][:2]) != "xx" || p.cells[0][24] != 'x' {
+		t.Errorf("cells misplaced:\n%s", bytes.Join(p.cells, []byte("\n")))
+	}
+}
+
+func Test_read_rle_states(t *testing.T) {
+	p, err := read_rle(strings.NewReader("x = 6, y = 2, rule = 23/3/3\nA.B$2BobA!\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p.cells[0]) != "x B   " || string(p.cells[1]) != "BBx x " {
+		t.Errorf("cells: %q", p.cells)
+	}
+	p, err = read_rle(strings.NewReader("x = 4, y = 1, rule = B2/S/C26\nXpA.A!\n"))
+	if err != nil || string(p.cells[0]) != "XY x" {
+		t.Errorf("prefixed states: %q %v", p.cells, err)
+	}
+
+	// a two state rule, also the default rule, only has 'o' or 'A'
+	for _, input := range []string{"x = 2, y = 1, rule = B3/S23\noB!", "x = 2, y = 1\nAB!", "x = 2, y = 1, rule = 23/3/3\npA!", "x = 2, y = 1\no*!"} {
+		if _, err := read_rle(strings.NewReader(input)); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
+
+func Test_rle_states_round_trip(t *testing.T) {
+	// every state of Brian's Brain and of a rule with prefixed states
+	for _, str := range []string{"/2/3", "B2/S/C26"} {
+		rule, _ := parse_rule(str)
+		board := NewBoard(30, 12)
+		board.rule = rule
+		for i := range board.data {
+			for k := range board.data[i] {
+				board.data[i][k] = state_cell((i * 7 + k * 3) % rule.states())
+			}
+		}
+
+		buf := &bytes.Buffer{}
+		if err := write_board(buf, board, "rle"); err != nil {
+			t.Fatalf("%s: %v", str, err)
+		}
+		p, err := read_rle(buf)
+		if err != nil || p.rule != rule.String() {
+			t.Fatalf("%s: got %q %v", str, p.rule, err)
+		}
+		again, _ := place_pattern(p, board.width, board.height, 0, 0)
+		if strings.Join(board_rows(again), "\n") != strings.Join(board_rows(board), "\n") {
+			t.Errorf("%s: got:\n%s", str, strings.Join(board_rows(again), "\n"))
+		}
+	}
+}
+
+func Test_rle_round_trip(t *testing.T) {
+	p, _ := read_rle(strings.NewReader(gosper_gun_rle))
+	board, err := place_pattern(p, 40, 40, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := write_rle(&buf, board); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if len(line) > 70 {
+			t.Errorf("line longer than 70: %q", line)
+		}
+	}
+
+	again, err := read_rle(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 40; i++ {
+		if string(again.cells[i]) != string(board.data[i]) {
+			t.Fatalf("row %d: got %q, want %q", i, again.cells[i], board.data[i])
+		}
+	}
+}
+
+func Test_read_cells(t *testing.T) {
+	input := "!Name: Glider\n!\n.O\n..O\nOOO\n"
+	p, err := read_cells(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.name != "Glider" || p.width != 3 || p.height != 3 {
+		t.Fatalf("got %q %dx%d", p.name, p.width, p.height)
+	}
+
+	board, _ := place_pattern(p, 5, 5, 1, 2)
+	var buf bytes.Buffer
+	write_cells(&buf, board)
+	want := ".....\n...O.\n....O\n..OOO\n.....\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	if _, err := read_cells(strings.NewReader(".O\n.#\n")); err == nil {
+		t.Error("expected error for unknown cell")
+	}
+}
+
+func Test_place_pattern(t *testing.T) {
+	p, _ := read_cells(strings.NewReader("OO\nOO\n"))
+
+	board, err := place_pattern(p, 0, 0, 1, 3)
+	if err != nil || board.width != 5 || board.height != 3 {
+		t.Fatalf("fit size: got %dx%d %v", board.width, board.height, err)
+	}
+	if string(board.data[2]) != "   xx" {
+		t.Errorf("got %q", board.data[2])
+	}
+
+	if _, err := place_pattern(p, 4, 4, 3, 0); err == nil {
+		t.Error("expected error for pattern outside the board")
+	}
+}
+
+func Test_read_board_rle_steps(t *testing.T) {
+	input := "x = 3, y = 1, rule = B36/S23\n3o!\n"
+	board, _, rule, err := read_board(strings.NewReader(input), "rle", "5", "2,1")
+	if err != nil || rule != "B36/S23" {
+		t.Fatal(rule, err)
+	}
+
+	// blinker turn vertical
+	end := board_rows(run_parallel(board, 1))
+	if end[1] != "  x  " || end[2] != "  x  " || end[3] != "  x  " {
+		t.Errorf("got:\n%s", strings.Join(end, "\n"))
+	}
+}
+
+func Test_read_board_text_placed(t *testing.T) {
+	input := "3 2 4\nxxx\n x\n"
+	board, step, _, err := read_board(strings.NewReader(input), "text", "6x4", "1,2")
+	if err != nil || step != 4 {
+		t.Fatal(step, err)
+	}
+	want := []string{"      ", "  xxx ", "   x  ", "      "}
+	if got := board_rows(board); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s", strings.Join(got, "\n"))
+	}
+
+	// the defaults keep the board of the header
+	board, _, _, _ = read_board(strings.NewReader(input), "text", "0", "0,0")
+	if board.width != 3 || board.height != 2 || !board_alive(board, 1, 1) {
+		t.Errorf("got %dx%d:\n%s", board.width, board.height, strings.Join(board_rows(board), "\n"))
+	}
+	if _, _, _, err := read_board(strings.NewReader(input), "text", "4", "0,2"); err == nil {
+		t.Error("expected error for a board outside the size")
+	}
+}
+
+func Test_write_board_text(t *testing.T) {
+	board, _, _ := read_input(strings.NewReader("4 0\n x\nxx x\n"))
+	buf := &bytes.Buffer{}
+	if err := write_board(buf, board, "text"); err != nil {
+		t.Fatal(err)
+	}
+	if want := " x  \nxx x\n    \n    \n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func Test_read_mcl(t *testing.T) {
+	mcl := "#MCell 4.20\n#GAME Special rules\n#RULE WireWorld\n#BOARD 80x60\n#D Electron on a wire\n#D second line\n#L 2.B\n#L A3C$$.C\n"
+	p, err := read_mcl(strings.NewReader(mcl))
+	if err != nil || p.rule != "WireWorld" || p.name != "Electron on a wire" {
+		t.Fatalf("got %q %q %v", p.rule, p.name, err)
+	}
+	want := []string{"  BxCCC", "       ", " C     "}
+	for i, row := range want {
+		if string(p.cells[i]) != row {
+			t.Errorf("row %d: got %q, want %q", i, p.cells[i], row)
+		}
+	}
+
+	// Life and Generations rules are survive first
+	for game, rule := range map[string]string{"Life": "S23/B3", "Generations": "12/34/3"} {
+		str := strings.Replace(strings.Replace(mcl, "Special rules", game, 1), "WireWorld", strings.TrimPrefix(strings.Replace(rule, "/B", "/", 1), "S"), 1)
+		if p, err := read_mcl(strings.NewReader(str)); err != nil || p.rule != rule {
+			t.Errorf("%s: got %q %v, want %q", game, p.rule, err, rule)
+		}
+	}
+
+	for _, str := range []string{"#L 3A\n", "#MCell 4.20\n#GAME Margolus\n#L A\n", "#MCell 4.20\n#L 2a\n"} {
+		if _, err := read_mcl(strings.NewReader(str)); err == nil {
+			t.Errorf("%q: expected error", str)
+		}
+	}
+}
+
+func Test_mcl_round_trip(t *testing.T) {
+	for _, str := range []string{"WireWorld", "B36/S23", "345/2/4"} {
+		board := NewBoard(90, 7)
+		board.rule, _ = parse_rule(str)
+		board.boundary = BoundaryWrap
+		for i := range board.data {
+			for k := range board.data[i] {
+				board.data[i][k] = state_cell((i * 7 + k * k) % board.rule.states())
+			}
+		}
+		board.data[3] = []byte(strings.Repeat(" ", 90))
+
+		buf := &bytes.Buffer{}
+		if err := write_board(buf, board, "mcl"); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "#BOARD 90x7\n#WRAP 1\n") {
+			t.Errorf("%s: header\n%s", str, buf.String())
+		}
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if len(line) > 73 {
+				t.Errorf("%s: line longer than 70: %q", str, line)
+			}
+		}
+
+		p, err := read_mcl(buf)
+		if err != nil {
+			t.Fatalf("%s: %v", str, err)
+		}
+		if rule, _ := parse_rule(p.rule); rule != board.rule {
+			t.Errorf("%s: read back rule %q", str, p.rule)
+		}
+		again, _ := place_pattern(p, 90, 7, 0, 0)
+		for i := range board.data {
+			if string(again.data[i]) != string(board.data[i]) {
+				t.Fatalf("%s row %d: got %q, want %q", str, i, again.data[i], board.data[i])
+			}
+		}
+	}
+
+	board := NewBoard(3, 3)
+	board.rule, _ = parse_rule("R2,C0,M1,S3..5,B3..4,NM")
+	if err := write_board(&bytes.Buffer{}, board, "mcl"); err == nil {
+		t.Error("expected error for a Larger than Life rule")
+	}
+}
@@ -0,0 +1,301 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ------------------ Data type -----------
+
+// Viewport is the part of the board drawn in a frame, width 0 draw the whole board
+type Viewport struct {
+	row, col      int
+	width, height int
+}
+
+type RenderOptions struct {
+	every int // draw every k-th generation
+	scale int // pixels per cell side
+	dead  color.RGBA
+	alive color.RGBA
+	view  Viewport
+	delay int // GIF frame delay in 100ths of a second
+}
+
+func NewRenderOptions() RenderOptions {
+	return RenderOptions{every: 1, scale: 4, dead: color.RGBA{255, 255, 255, 255}, alive: color.RGBA{0, 0, 0, 255}, delay: 10}
+}
+
+// frame on its way through the pipeline, cells are a copy of the viewport
+type Frame struct {
+	generation int
+	cells      [][]byte
+	image      *image.Paletted
+}
+
+// Renderer draw the frames in a pipeline next to the simulation:
+//	engine observer -> copy viewport -> frames -> draw -> images -> encode
+// only the viewport copy run on the engine goroutine
+type Renderer struct {
+	opts    RenderOptions
+	palette color.Palette
+	gif     io.Writer // animated GIF output, nil for a PNG sequence
+	pattern string    // PNG file name, %d is the generation
+	file    io.Closer // closed with the renderer, nil if not owned
+	frames  chan Frame
+	images  chan Frame
+	done    chan error
+	mu      sync.Mutex
+	closed  bool
+}
+
+// frames queued between two stages before the engine wait for the renderer
+const render_queue = 16
+
+// -------------------- problem solving functions ----------------------
+
+// w get an animated GIF, or with w nil every frame is a PNG file named by pattern
+func NewRenderer(w io.Writer, pattern string, opts RenderOptions, states int) (*Renderer, error) {
+	if opts.every < 1 || opts.scale < 1 {
+		return nil, errors.New("Render interval and scale must be positive")
+	}
+	if w == nil && strings.Count(pattern, "%") != 1 {
+		return nil, errors.New("PNG file name need one %d for the generation: " + pattern)
+	}
+
+	r := &Renderer{opts: opts, gif: w, pattern: pattern, palette: state_palette(opts.dead, opts.alive, states),
+		frames: make(chan Frame, render_queue), images: make(chan Frame, render_queue), done: make(chan error, 1)}
+	go r.draw()
+	go r.encode()
+	return r, nil
+}
+
+// dead, alive and the dying states of a Generations rule fading from alive to dead
+func state_palette(dead, alive color.RGBA, states int) color.Palette {
+	palette := color.Palette{dead, alive}
+	for state := 2; state < states; state++ {
+		blend := func(a, b uint8) uint8 {
+			return uint8((int(a) * (states - state) + int(b) * (state - 1)) / (states - 1))
+		}
+		palette = append(palette, color.RGBA{blend(alive.R, dead.R), blend(alive.G, dead.G), blend(alive.B, dead.B), 255})
+	}
+	return palette
+}
+
+// viewport "row,col,WxH", empty for the whole board
+func parse_viewport(str string) (Viewport, error) {
+	v := Viewport{}
+	if str == "" {
+		return v, nil
+	}
+	_, err := fmt.Sscanf(str, "%d,%d,%dx%d", &v.row, &v.col, &v.width, &v.height)
+	if err != nil || v.width < 1 || v.height < 1 {
+		return v, errors.New("Invalid viewport: " + str)
+	}
+	return v, nil
+}
+
+// colours "dead,alive" as hex rrggbb, with or without #
+func parse_colors(str string) (color.RGBA, color.RGBA, error) {
+	var c [2]color.RGBA
+	parts := strings.Split(str, ",")
+	if len(parts) != 2 {
+		return c[0], c[1], errors.New("Invalid colors: " + str)
+	}
+	for i, part := range parts {
+		c[i].A = 255
+		hex := strings.TrimPrefix(strings.TrimSpace(part), "#")
+		if _, err := fmt.Sscanf(hex, "%02x%02x%02x", &c[i].R, &c[i].G, &c[i].B); err != nil || len(hex) != 6 {
+			return c[0], c[1], errors.New("Invalid colors: " + str)
+		}
+	}
+	return c[0], c[1], nil
+}
+
+// copy of the viewport, cells outside of the board are dead
+func crop_board(b Board, v Viewport) [][]byte {
+	if v.width == 0 {
+		v = Viewport{width: b.width, height: b.height}
+	}
+	cells := make([][]byte, v.height)
+	for i := range cells {
+		cells[i] = make([]byte, v.width)
+		row := v.row + i
+		if row < 0 || row >= b.height {
+			continue
+		}
+		for k := range cells[i] {
+			if col := v.col + k; col >= 0 && col < len(b.data[row]) {
+				cells[i][k] = b.data[row][col]
+			}
+		}
+	}
+	return cells
+}
+
+// queue a frame, block only when both stages are full
+func (r *Renderer) add(generation int, b Board) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || generation % r.opts.every != 0 {
+		return
+	}
+	r.frames <- Frame{generation: generation, cells: crop_board(b, r.opts.view)}
+}
+
+// cells as a square of scale pixels each, the colour of a state is its palette index
+func draw_cells(cells [][]byte, scale int, palette color.Palette) *image.Paletted {
+	height, width := len(cells), 0
+	if height > 0 {
+		width = len(cells[0])
+	}
+	img := image.NewPaletted(image.Rect(0, 0, width * scale, height * scale), palette)
+	for i, row := range cells {
+		for k, cell := range row {
+			index := uint8(min(cell_state(cell), len(palette) - 1))
+			if index == 0 {
+				continue // palette index 0 is the dead colour
+			}
+			for y := i * scale; y < (i + 1) * scale; y++ {
+				line := img.Pix[y * img.Stride:]
+				for x := k * scale; x < (k + 1) * scale; x++ {
+					line[x] = index
+				}
+			}
+		}
+	}
+	return img
+}
+
+// stage 1: cells to paletted image
+func (r *Renderer) draw() {
+	for f := range r.frames {
+		f.cells, f.image = nil, draw_cells(f.cells, r.opts.scale, r.palette)
+		r.images <- f
+	}
+	close(r.images)
+}
+
+// stage 2: write the PNG files or collect the GIF frames, in generation order
+func (r *Renderer) encode() {
+	anim := &gif.GIF{}
+	var err error
+	for f := range r.images {
+		if err != nil {
+			continue // drain the pipeline after the first error
+		}
+		if r.gif != nil {
+			anim.Image = append(anim.Image, f.image)
+			anim.Delay = append(anim.Delay, r.opts.delay)
+			continue
+		}
+		err = write_png(fmt.Sprintf(r.pattern, f.generation), f.image)
+	}
+	if err == nil && r.gif != nil {
+		if len(anim.Image) == 0 {
+			err = errors.New("No frame to render")
+		} else {
+			err = gif.EncodeAll(r.gif, anim)
+		}
+	}
+	r.done <- err
+}
+
+func write_png(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(file, img)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// wait for the queued frames, write the GIF and close the file, report the first error
+func (r *Renderer) close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return errors.New("Renderer is already closed")
+	}
+	r.closed = true
+	close(r.frames)
+	r.mu.Unlock()
+
+	err := <-r.done
+	if r.file != nil {
+		if cerr := r.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// .gif path is an animated GIF, other paths are a PNG file name pattern with %d for the generation
+func open_render(e *Engine, path string, opts RenderOptions) (*Renderer, error) {
+	var w io.Writer
+	var file *os.File
+	pattern := path
+	if strings.HasSuffix(strings.ToLower(path), ".gif") {
+		var err error
+		if file, err = os.Create(path); err != nil {
+			return nil, err
+		}
+		w, pattern = file, ""
+	}
+
+	r, err := NewRenderer(w, pattern, opts, e.Snapshot().rule.states())
+	if err != nil {
+		if file != nil {
+			file.Close()
+		}
+		return nil, err
+	}
+	if file != nil {
+		r.file = file
+	}
+	if err := e.Render(r); err != nil {
+		r.close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// draw every k-th generation the engine play, starting with the current one
+func (e *Engine) Render(r *Renderer) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.kernel == "bit" && e.pool == nil {
+		return errors.New("Engine is not started")
+	}
+
+	view := func() Board {
+		// the byte kernel board is replaced by the next generation, never written, a copy is not needed
+		if e.kernel == "bit" {
+			return unpack_board(e.bits)
+		}
+		return e.board
+	}
+	r.add(e.generation, view())
+	e.observers = append(e.observers, func(generation int, s Stats) {
+		if generation % r.opts.every == 0 {
+			r.add(generation, view())
+		}
+	})
+	return nil
+}
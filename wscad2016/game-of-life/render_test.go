@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_parse_viewport(t *testing.T) {
+	if v, err := parse_viewport("2,3,10x5"); err != nil || v != (Viewport{2, 3, 10, 5}) {
+		t.Errorf("got %v %v", v, err)
+	}
+	if v, err := parse_viewport(""); err != nil || v.width != 0 {
+		t.Errorf("empty: got %v %v", v, err)
+	}
+	for _, str := range []string{"2,3", "2,3,0x5", "a,b,1x1"} {
+		if _, err := parse_viewport(str); err == nil {
+			t.Errorf("%q: expected error", str)
+		}
+	}
+}
+
+func Test_parse_colors(t *testing.T) {
+	dead, alive, err := parse_colors("#102030,ff8000")
+	if err != nil || dead != (color.RGBA{0x10, 0x20, 0x30, 255}) || alive != (color.RGBA{0xff, 0x80, 0, 255}) {
+		t.Errorf("got %v %v %v", dead, alive, err)
+	}
+	for _, str := range []string{"ffffff", "fffff,000000", "gggggg,000000"} {
+		if _, _, err := parse_colors(str); err == nil {
+			t.Errorf("%q: expected error", str)
+		}
+	}
+}
+
+func Test_render_gif(t *testing.T) {
+	for _, kernel := range []string{"byte", "bit", "table"} {
+		board := new_test_board(t, glider)
+		e := new_test_engine(t, board, kernel)
+
+		buf := &bytes.Buffer{}
+		opts := NewRenderOptions()
+		opts.every, opts.scale = 2, 3
+		r, err := NewRenderer(buf, "", opts, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Render(r); err != nil {
+			t.Fatal(err)
+		}
+		e.Step(8)
+		if err := r.close(); err != nil {
+			t.Fatal(err)
+		}
+
+		anim, err := gif.DecodeAll(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// generation 0, 2, 4, 6 and 8
+		if len(anim.Image) != 5 || anim.Image[0].Bounds().Dx() != 24 || anim.Image[0].Bounds().Dy() != 24 {
+			t.Fatalf("%s: got %d frames of %v", kernel, len(anim.Image), anim.Image[0].Bounds())
+		}
+		// last frame is the glider moved 2 cells, [4,4] is alive, [0,1] dead
+		last := anim.Image[4]
+		if last.At(4 * 3 + 1, 4 * 3 + 2) != opts.alive || last.At(1 * 3, 0) != opts.dead {
+			t.Errorf("%s: wrong cell colours in the last frame", kernel)
+		}
+	}
+}
+
+func Test_render_png_view(t *testing.T) {
+	dir := t.TempDir()
+	board := new_test_board(t, glider)
+	board.rule, _ = parse_rule("/2/3")
+	e := new_test_engine(t, board, "byte")
+
+	opts := NewRenderOptions()
+	opts.scale, opts.view = 1, Viewport{row: 1, col: 1, width: 4, height: 2}
+	r, err := open_render(e, filepath.Join(dir, "gen%03d.png"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Step(1)
+	if err := r.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 2 || files[1].Name() != "gen001.png" {
+		t.Fatalf("got %d files", len(files))
+	}
+	file, _ := os.Open(filepath.Join(dir, "gen000.png"))
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rows 1 and 2 from column 1
+	if img.Bounds().Dx() != 4 || img.Bounds().Dy() != 2 {
+		t.Fatalf("got %v", img.Bounds())
+	}
+	want := []string{" x  ", "xx  "}
+	for y, row := range want {
+		for x := range row {
+			c := color.RGBAModel.Convert(img.At(x, y))
+			if (row[x] == 'x') != (c == opts.alive) {
+				t.Errorf("pixel %d,%d: got %v", x, y, c)
+			}
+		}
+	}
+}
+
+func Test_state_palette(t *testing.T) {
+	dead, alive := color.RGBA{255, 255, 255, 255}, color.RGBA{0, 0, 0, 255}
+	p := state_palette(dead, alive, 4)
+	if len(p) != 4 || p[0] != dead || p[1] != alive {
+		t.Fatalf("got %v", p)
+	}
+	// dying states fade from alive to dead
+	if p[2].(color.RGBA).R <= 0 || p[2].(color.RGBA).R >= p[3].(color.RGBA).R {
+		t.Errorf("got %v", p)
+	}
+}
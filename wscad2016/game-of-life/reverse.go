@@ -0,0 +1,226 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ------------------ Data type -----------
+
+// Reverse is the CNF of the generations that lead to a target board: cells[g][r][c] is the
+// literal of cell [r,c] at generation g, generation 0 is the predecessor and the last one
+// the target as constants. Cells off a dead boundary are dead in every generation, like the
+// engines play the board.
+type Reverse struct {
+	target      Board
+	generations int
+	cnf         *CNF
+	cells       [][][]int
+}
+
+// -------------------- problem solving functions ----------------------
+
+// board with margin dead cells added on every side
+func pad_board(b Board, margin int) Board {
+	padded := NewBoard(b.width + 2 * margin, b.height + 2 * margin)
+	padded.rule, padded.boundary = b.rule, b.boundary
+	for i := 0; i < padded.height; i++ {
+		for k := 0; k < padded.width; k++ {
+			alive := 0
+			if i >= margin && i < margin + b.height && k >= margin && k < margin + b.width && board_alive(b, i - margin, k - margin) {
+				alive = 1
+			}
+			padded.data[i][k] = set_life_status(alive)
+		}
+	}
+	return padded
+}
+
+func NewReverse(target Board, generations int) (*Reverse, error) {
+	if err := check_life_like("Predecessor search", target.rule); err != nil {
+		return nil, err
+	}
+	if generations < 1 {
+		return nil, errors.New("Predecessor search need at least one generation")
+	}
+
+	r := &Reverse{target: target, generations: generations, cnf: NewCNF()}
+	r.cells = make([][][]int, generations + 1)
+	for g := range r.cells {
+		r.cells[g] = make([][]int, target.height)
+		for i := range r.cells[g] {
+			r.cells[g][i] = make([]int, target.width)
+			for k := range r.cells[g][i] {
+				switch {
+				case g < generations:
+					r.cells[g][i][k] = r.cnf.new_var()
+				case board_alive(target, i, k):
+					r.cells[g][i][k] = lit_true
+				default:
+					r.cells[g][i][k] = lit_false
+				}
+			}
+		}
+	}
+
+	for g := 1; g <= generations; g++ {
+		for i := 0; i < target.height; i++ {
+			for k := 0; k < target.width; k++ {
+				r.transition(r.cells[g][i][k], r.cells[g - 1][i][k], r.neighbours(g - 1, i, k))
+			}
+		}
+	}
+	return r, nil
+}
+
+// literals of the eight neighbours of cell [i,k] at generation g, without the dead cells off the board
+func (r *Reverse) neighbours(g, i, k int) []int {
+	b := r.target
+	lits := make([]int, 0, 8)
+	for dr := -1; dr <= 1; dr++ {
+		for dc := -1; dc <= 1; dc++ {
+			if dr == 0 && dc == 0 {
+				continue
+			}
+			row, col := i + dr, k + dc
+			if b.boundary == BoundaryWrap {
+				row, col = (row + b.height) % b.height, (col + b.width) % b.width
+			}
+			if row >= 0 && row < b.height && col >= 0 && col < b.width {
+				lits = append(lits, r.cells[g][row][col])
+			}
+		}
+	}
+	return lits
+}
+
+// next is the rule applied to current and the count of the neighbours:
+// for every count k and current state, exactly k neighbours and that state imply the next state
+func (r *Reverse) transition(next, current int, neighbours []int) {
+	at := r.cnf.unary_count(neighbours)
+	for k := 0; k <= 8; k++ {
+		if k >= len(at) {
+			break
+		}
+		above := lit_false
+		if k + 1 < len(at) {
+			above = at[k + 1]
+		}
+		for state, lit := range []int{current, -current} {
+			// lit is false in the state, the clause apply to that state only
+			out := -next
+			if game_of_life_status(r.target.rule, state, k) == 1 {
+				out = next
+			}
+			r.cnf.add(-at[k], above, lit, out)
+		}
+	}
+}
+
+// predecessor board, generation 0 of the model
+func (r *Reverse) decode(model []bool) Board {
+	b := NewBoard(r.target.width, r.target.height)
+	b.rule, b.boundary = r.target.rule, r.target.boundary
+	for i := range b.data {
+		for k := range b.data[i] {
+			alive := 0
+			if r.cnf.value(model, r.cells[0][i][k]) {
+				alive = 1
+			}
+			b.data[i][k] = set_life_status(alive)
+		}
+	}
+	return b
+}
+
+// predecessor of the target with the built-in solver, ok is false when there is none
+func (r *Reverse) solve(ctx context.Context) (Board, bool, error) {
+	s := NewSolver(r.cnf)
+	sat, err := s.solve(ctx)
+	if err != nil || !sat {
+		return Board{}, false, err
+	}
+	return r.decode(s.model), true, nil
+}
+
+// ------------  input, output -----------
+
+// board in the read_input format, the header step is the generations to the target
+func write_input(w io.Writer, b Board, step int) error {
+	if _, err := fmt.Fprintf(w, "%d %d %d\n", b.width, b.height, step); err != nil {
+		return err
+	}
+	for _, row := range b.data {
+		if _, err := fmt.Fprintf(w, "%s\n", row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// predecessor search of the main: write the CNF to dimacs, decode the model file of an
+// external solver or solve it, then write the predecessor board
+func run_reverse(ctx context.Context, target Board, generations, margin int, dimacs, model_path, output string) error {
+	if margin > 0 {
+		if target.boundary != BoundaryDead {
+			return errors.New("Predecessor margin need the dead boundary")
+		}
+		target = pad_board(target, margin)
+	}
+	r, err := NewReverse(target, generations)
+	if err != nil {
+		return err
+	}
+
+	if dimacs != "" {
+		file, err := os.Create(dimacs)
+		if err != nil {
+			return err
+		}
+		err = write_dimacs(file, r.cnf)
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "%d variables, %d clauses written to %s\n", r.cnf.vars, len(r.cnf.clauses), dimacs)
+		}
+		return err
+	}
+
+	var board Board
+	found := false
+	if model_path != "" {
+		file, err := os.Open(model_path)
+		if err != nil {
+			return err
+		}
+		model, err := read_dimacs_model(file, r.cnf.vars)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		if model != nil && !r.cnf.satisfied(model) {
+			return errors.New("Model does not satisfy the predecessor clauses")
+		}
+		if model != nil {
+			board, found = r.decode(model), true
+		}
+	} else if board, found, err = r.solve(ctx); err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("No predecessor of the %dx%d board %d generations back", target.width, target.height, generations)
+	}
+
+	if output == "text" {
+		return write_input(os.Stdout, board, generations)
+	}
+	return write_board(os.Stdout, board, output)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func Test_reverse_play_forward(t *testing.T) {
+	// the predecessor of a played board play into it again
+	cases := []struct {
+		rule        string
+		boundary    Boundary
+		size        int
+		generations int
+	}{
+		{"B3/S23", BoundaryDead, 12, 1},
+		{"B3/S23", BoundaryWrap, 12, 1},
+		{"B36/S23", BoundaryDead, 10, 1},
+		{"B3/S23", BoundaryDead, 7, 2},
+	}
+	for _, tc := range cases {
+		board := new_random_board(tc.size, tc.size, 11)
+		board.rule, _ = parse_rule(tc.rule)
+		board.boundary = tc.boundary
+		target := run_parallel(board, tc.generations)
+
+		r, err := NewReverse(target, tc.generations)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pred, ok, err := r.solve(context.Background())
+		if err != nil || !ok {
+			t.Fatalf("%s boundary %d: got %v %v", tc.rule, tc.boundary, ok, err)
+		}
+		if got := run_parallel(pred, tc.generations); !same_board(got, target) {
+			t.Errorf("%s boundary %d: predecessor\n%s\nplay into\n%s", tc.rule, tc.boundary,
+				strings.Join(board_rows(pred), "\n"), strings.Join(board_rows(got), "\n"))
+		}
+	}
+}
+
+func Test_reverse_exhaustive(t *testing.T) {
+	// every 4x4 board with a dead boundary, a target has a predecessor exactly when one of them play into it
+	const size = 4
+	board_of := func(bits int) Board {
+		b := NewBoard(size, size)
+		for i := 0; i < size * size; i++ {
+			b.data[i / size][i % size] = set_life_status(bits >> uint(i) & 1)
+		}
+		return b
+	}
+	reachable := make(map[string]bool)
+	for bits := 0; bits < 1 << (size * size); bits++ {
+		reachable[strings.Join(board_rows(play_reference(board_of(bits))), "/")] = true
+	}
+
+	rnd := rand.New(rand.NewSource(3))
+	found := 0
+	for i := 0; i < 300; i++ {
+		target := board_of(rnd.Intn(1 << (size * size)))
+		r, _ := NewReverse(target, 1)
+		pred, ok, err := r.solve(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := reachable[strings.Join(board_rows(target), "/")]
+		if ok != want || ok && !same_board(play_reference(pred), target) {
+			t.Fatalf("target %q: got %v, want %v", board_rows(target), ok, want)
+		}
+		if ok {
+			found++
+		}
+	}
+	if found == 0 || found == 300 {
+		t.Errorf("got %d targets with a predecessor of 300", found)
+	}
+}
+
+func Test_reverse_margin(t *testing.T) {
+	// a block on its 2x2 board has no room for anything but itself, a margin allow other parents
+	board, _, _ := read_input(strings.NewReader("2 2 0\nxx\nxx\n"))
+	padded := pad_board(board, 2)
+	if padded.width != 6 || !board_alive(padded, 2, 2) || board_alive(padded, 1, 2) || !same_board(run_parallel(padded, 1), padded) {
+		t.Errorf("got padded board %q", board_rows(padded))
+	}
+	r, _ := NewReverse(padded, 2)
+	pred, ok, err := r.solve(context.Background())
+	if err != nil || !ok || !same_board(run_parallel(pred, 2), padded) {
+		t.Errorf("got %v %v", ok, err)
+	}
+}
+
+func Test_reverse_unsupported(t *testing.T) {
+	board := new_random_board(5, 5, 1)
+	board.rule, _ = parse_rule("/2/3")
+	if _, err := NewReverse(board, 1); err == nil {
+		t.Error("expected error for a Generations rule")
+	}
+	board.rule = Conway
+	if _, err := NewReverse(board, 0); err == nil {
+		t.Error("expected error for no generation")
+	}
+}
+
+func Test_write_input(t *testing.T) {
+	board := new_random_board(6, 4, 2)
+	buf := &bytes.Buffer{}
+	write_input(buf, board, 3)
+	got, step, err := read_input(buf)
+	if err != nil || step != 3 || !same_board(got, board) {
+		t.Errorf("got %q %d %v", board_rows(got), step, err)
+	}
+}
@@ -0,0 +1,662 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ------------------ Data type -----------
+
+// CNF is a formula in DIMACS numbering, variable v is literal v and its negation -v,
+// variable 1 is always true
+type CNF struct {
+	vars    int
+	clauses [][]int
+}
+
+const lit_true, lit_false = 1, -1
+
+func NewCNF() *CNF {
+	return &CNF{vars: 1, clauses: [][]int{{lit_true}}}
+}
+
+// Clause of the solver, lits[0] and lits[1] are watched, lits[0] is the implied literal of a reason
+type Clause struct {
+	lits     []int // solver literals, 2 * variable + 1 if negated
+	learnt   bool
+	deleted  bool
+	activity float64
+	lbd      int // decision levels of a learnt clause when it was learnt
+}
+
+// Watch of a clause, a true blocker satisfy the clause without reading it
+type Watch struct {
+	clause  *Clause
+	blocker int
+}
+
+// Solver is a CDCL SAT solver: two watched literals, first UIP learning with minimization,
+// VSIDS decisions with saved phases, Luby restarts and a learnt clause limit
+type Solver struct {
+	vars      int
+	clauses   []*Clause
+	learnts   []*Clause
+	watches   [][]Watch // clauses watching a literal, visited when it become false
+	assigns   []int8      // of every variable: 0 unassigned, 1 true, -1 false
+	level     []int
+	reason    []*Clause
+	phase     []bool // last value of every variable
+	trail     []int  // literals in assignment order
+	trail_lim []int  // trail length at every decision
+	qhead     int
+	activity  []float64
+	var_inc   float64
+	cla_inc   float64
+	order     VarHeap
+	seen      []bool
+	ok        bool // false once the clauses are unsatisfiable at level 0
+	conflicts int
+	model     []bool // of every DIMACS variable after a satisfiable solve, index 0 is unused
+}
+
+// VarHeap is a max heap of the unassigned variables by activity
+type VarHeap struct {
+	heap     []int
+	index    []int // position of every variable in heap, -1 if not in it
+	activity *[]float64
+}
+
+// conflicts of the first restart, multiplied by the Luby sequence
+const restart_unit = 100
+
+// conflicts before the first learnt clause reduction, the interval grow by reduce_step
+const reduce_first, reduce_step = 2000, 300
+
+// -------------------- problem solving functions ----------------------
+
+func (f *CNF) new_var() int {
+	f.vars++
+	return f.vars
+}
+
+// add a clause, drop the false literals and the clauses with a true one, an
+// empty clause make the formula unsatisfiable
+func (f *CNF) add(lits ...int) {
+	clause := make([]int, 0, len(lits))
+	for _, lit := range lits {
+		switch lit {
+		case lit_true:
+			return
+		case lit_false:
+			continue
+		}
+		clause = append(clause, lit)
+	}
+	if len(clause) == 0 {
+		clause = append(clause, lit_false)
+	}
+	f.clauses = append(f.clauses, clause)
+}
+
+// new variable y <=> a or (b and x), constants are folded
+func (f *CNF) or_and(a, b, x int) int {
+	switch {
+	case a == lit_true || b == lit_true && x == lit_true:
+		return lit_true
+	case a == lit_false && (b == lit_false || x == lit_false):
+		return lit_false
+	case a == lit_false && b == lit_true:
+		return x
+	case a == lit_false && x == lit_true:
+		return b
+	}
+	y := f.new_var()
+	f.add(-a, y)
+	f.add(-b, -x, y)
+	f.add(-y, a, b)
+	f.add(-y, a, x)
+	return y
+}
+
+// at[j] is true exactly when at least j of the inputs are true, for j from 0 to len(inputs)
+func (f *CNF) unary_count(inputs []int) []int {
+	at := []int{lit_true}
+	for _, x := range inputs {
+		next := make([]int, len(at) + 1)
+		next[0] = lit_true
+		for j := 1; j <= len(at); j++ {
+			above := lit_false
+			if j < len(at) {
+				above = at[j]
+			}
+			next[j] = f.or_and(above, at[j - 1], x)
+		}
+		at = next
+	}
+	return at
+}
+
+func (f *CNF) value(model []bool, lit int) bool {
+	if lit < 0 {
+		return !model[-lit]
+	}
+	return model[lit]
+}
+
+// every clause has a true literal in the model
+func (f *CNF) satisfied(model []bool) bool {
+	for _, clause := range f.clauses {
+		ok := false
+		for _, lit := range clause {
+			ok = ok || f.value(model, lit)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func solver_lit(lit int) int {
+	if lit < 0 {
+		return 2 * (-lit - 1) + 1
+	}
+	return 2 * (lit - 1)
+}
+
+func NewSolver(f *CNF) *Solver {
+	s := &Solver{vars: f.vars, var_inc: 1, cla_inc: 1, ok: true}
+	s.watches = make([][]Watch, 2 * f.vars)
+	s.assigns = make([]int8, f.vars)
+	s.level = make([]int, f.vars)
+	s.reason = make([]*Clause, f.vars)
+	s.phase = make([]bool, f.vars)
+	s.activity = make([]float64, f.vars)
+	s.seen = make([]bool, f.vars)
+	s.order = VarHeap{index: make([]int, f.vars), activity: &s.activity}
+	for v := 0; v < f.vars; v++ {
+		s.order.index[v] = -1
+		s.order.push(v)
+	}
+	for _, clause := range f.clauses {
+		lits := make([]int, len(clause))
+		for i, lit := range clause {
+			lits[i] = solver_lit(lit)
+		}
+		s.add_clause(lits)
+	}
+	return s
+}
+
+func (s *Solver) value(lit int) int8 {
+	v := s.assigns[lit >> 1]
+	if lit & 1 != 0 {
+		return -v
+	}
+	return v
+}
+
+func (s *Solver) decision_level() int {
+	return len(s.trail_lim)
+}
+
+// add a clause at level 0, satisfied clauses and false literals are dropped
+func (s *Solver) add_clause(lits []int) {
+	if !s.ok {
+		return
+	}
+	sort.Ints(lits)
+	clause := lits[:0]
+	for i, lit := range lits {
+		if s.value(lit) == 1 || i > 0 && lit == lits[i - 1] ^ 1 {
+			return
+		}
+		if s.value(lit) == -1 || i > 0 && lit == lits[i - 1] {
+			continue
+		}
+		clause = append(clause, lit)
+	}
+	switch len(clause) {
+	case 0:
+		s.ok = false
+	case 1:
+		s.enqueue(clause[0], nil)
+		s.ok = s.propagate() == nil
+	default:
+		c := &Clause{lits: append([]int(nil), clause...)}
+		s.attach(c)
+		s.clauses = append(s.clauses, c)
+	}
+}
+
+func (s *Solver) attach(c *Clause) {
+	s.watches[c.lits[0]] = append(s.watches[c.lits[0]], Watch{c, c.lits[1]})
+	s.watches[c.lits[1]] = append(s.watches[c.lits[1]], Watch{c, c.lits[0]})
+}
+
+func (s *Solver) enqueue(lit int, reason *Clause) {
+	v := lit >> 1
+	s.assigns[v] = 1
+	if lit & 1 != 0 {
+		s.assigns[v] = -1
+	}
+	s.level[v] = s.decision_level()
+	s.reason[v] = reason
+	s.trail = append(s.trail, lit)
+}
+
+// unit propagation of the trail, return the conflicting clause or nil
+func (s *Solver) propagate() *Clause {
+	for s.qhead < len(s.trail) {
+		false_lit := s.trail[s.qhead] ^ 1
+		s.qhead++
+		ws := s.watches[false_lit]
+		i, j := 0, 0
+		for i < len(ws) {
+			w := ws[i]
+			i++
+			if s.value(w.blocker) == 1 {
+				ws[j] = w
+				j++
+				continue
+			}
+			c := w.clause
+			if c.deleted {
+				continue
+			}
+			if c.lits[0] == false_lit {
+				c.lits[0], c.lits[1] = c.lits[1], c.lits[0]
+			}
+			w.blocker = c.lits[0]
+			if s.value(c.lits[0]) == 1 {
+				ws[j] = w
+				j++
+				continue
+			}
+			// look for a new literal to watch
+			moved := false
+			for k := 2; k < len(c.lits); k++ {
+				if s.value(c.lits[k]) != -1 {
+					c.lits[1], c.lits[k] = c.lits[k], c.lits[1]
+					s.watches[c.lits[1]] = append(s.watches[c.lits[1]], Watch{c, c.lits[0]})
+					moved = true
+					break
+				}
+			}
+			if moved {
+				continue
+			}
+			ws[j] = w
+			j++
+			if s.value(c.lits[0]) == -1 {
+				for i < len(ws) {
+					ws[j] = ws[i]
+					i, j = i + 1, j + 1
+				}
+				s.watches[false_lit] = ws[:j]
+				s.qhead = len(s.trail)
+				return c
+			}
+			s.enqueue(c.lits[0], c)
+		}
+		s.watches[false_lit] = ws[:j]
+	}
+	return nil
+}
+
+func (s *Solver) bump_var(v int) {
+	s.activity[v] += s.var_inc
+	if s.activity[v] > 1e100 {
+		for i := range s.activity {
+			s.activity[i] *= 1e-100
+		}
+		s.var_inc *= 1e-100
+	}
+	if s.order.index[v] >= 0 {
+		s.order.up(s.order.index[v])
+	}
+}
+
+func (s *Solver) bump_clause(c *Clause) {
+	c.activity += s.cla_inc
+	if c.activity > 1e20 {
+		for _, l := range s.learnts {
+			l.activity *= 1e-20
+		}
+		s.cla_inc *= 1e-20
+	}
+}
+
+// first UIP clause of the conflict, the asserting literal first, with the level to go back to
+func (s *Solver) analyze(confl *Clause) ([]int, int) {
+	learnt := []int{-1}
+	paths := 0
+	lit := -1
+	index := len(s.trail) - 1
+	for {
+		if confl.learnt {
+			s.bump_clause(confl)
+		}
+		start := 0
+		if lit != -1 {
+			start = 1
+		}
+		for _, q := range confl.lits[start:] {
+			v := q >> 1
+			if s.seen[v] || s.level[v] == 0 {
+				continue
+			}
+			s.bump_var(v)
+			s.seen[v] = true
+			if s.level[v] >= s.decision_level() {
+				paths++
+			} else {
+				learnt = append(learnt, q)
+			}
+		}
+		for !s.seen[s.trail[index] >> 1] {
+			index--
+		}
+		lit = s.trail[index]
+		index--
+		confl = s.reason[lit >> 1]
+		s.seen[lit >> 1] = false
+		paths--
+		if paths == 0 {
+			break
+		}
+	}
+	learnt[0] = lit ^ 1
+
+	// drop the literals implied by the other ones of the clause
+	kept := []int{learnt[0]}
+	for _, q := range learnt[1:] {
+		reason := s.reason[q >> 1]
+		redundant := reason != nil
+		if reason != nil {
+			for _, r := range reason.lits[1:] {
+				if !s.seen[r >> 1] && s.level[r >> 1] > 0 {
+					redundant = false
+					break
+				}
+			}
+		}
+		if !redundant {
+			kept = append(kept, q)
+		}
+	}
+	for _, q := range learnt[1:] {
+		s.seen[q >> 1] = false
+	}
+	learnt = kept
+
+	// the highest other level is the second watch
+	back := 0
+	for i := 1; i < len(learnt); i++ {
+		if s.level[learnt[i] >> 1] > s.level[learnt[1] >> 1] {
+			learnt[1], learnt[i] = learnt[i], learnt[1]
+		}
+	}
+	if len(learnt) > 1 {
+		back = s.level[learnt[1] >> 1]
+	}
+	return learnt, back
+}
+
+func (s *Solver) cancel_until(level int) {
+	if s.decision_level() <= level {
+		return
+	}
+	for i := len(s.trail) - 1; i >= s.trail_lim[level]; i-- {
+		v := s.trail[i] >> 1
+		s.phase[v] = s.assigns[v] == 1
+		s.assigns[v] = 0
+		s.reason[v] = nil
+		if s.order.index[v] < 0 {
+			s.order.push(v)
+		}
+	}
+	s.trail = s.trail[:s.trail_lim[level]]
+	s.trail_lim = s.trail_lim[:level]
+	s.qhead = len(s.trail)
+}
+
+// clause is the reason of its implied literal
+func (s *Solver) locked(c *Clause) bool {
+	v := c.lits[0] >> 1
+	return s.reason[v] == c && s.value(c.lits[0]) == 1
+}
+
+// distinct decision levels of the literals
+func (s *Solver) lbd(lits []int) int {
+	levels := make(map[int]bool, len(lits))
+	for _, lit := range lits {
+		levels[s.level[lit >> 1]] = true
+	}
+	return len(levels)
+}
+
+// delete the worse half of the learnt clauses, most levels then least active first,
+// binary, two level and reason clauses stay
+func (s *Solver) reduce_learnts() {
+	sort.Slice(s.learnts, func(i, j int) bool {
+		a, b := s.learnts[i], s.learnts[j]
+		if a.lbd != b.lbd {
+			return a.lbd > b.lbd
+		}
+		return a.activity < b.activity
+	})
+	kept := s.learnts[:0]
+	for i, c := range s.learnts {
+		if i < len(s.learnts) / 2 && len(c.lits) > 2 && c.lbd > 2 && !s.locked(c) {
+			c.deleted = true
+			continue
+		}
+		kept = append(kept, c)
+	}
+	s.learnts = kept
+}
+
+// i-th term of the Luby sequence 1 1 2 1 1 2 4 1 1 2 ...
+func luby(i int) int {
+	size, exp := 1, 0
+	for size < i + 1 {
+		size, exp = 2 * size + 1, exp + 1
+	}
+	for size - 1 != i {
+		size = (size - 1) / 2
+		exp--
+		i = i % size
+	}
+	return 1 << uint(exp)
+}
+
+// search until a model, a proof of unsatisfiability or the end of the context
+func (s *Solver) solve(ctx context.Context) (bool, error) {
+	if !s.ok {
+		return false, nil
+	}
+	if s.propagate() != nil {
+		s.ok = false
+		return false, nil
+	}
+	next_reduce := reduce_first
+	for restart := 0; ; restart++ {
+		budget := restart_unit * luby(restart)
+		for budget > 0 {
+			confl := s.propagate()
+			if confl != nil {
+				s.conflicts++
+				budget--
+				if s.decision_level() == 0 {
+					s.ok = false
+					return false, nil
+				}
+				learnt, back := s.analyze(confl)
+				s.cancel_until(back)
+				if len(learnt) == 1 {
+					s.enqueue(learnt[0], nil)
+				} else {
+					c := &Clause{lits: learnt, learnt: true, lbd: s.lbd(learnt)}
+					s.attach(c)
+					s.learnts = append(s.learnts, c)
+					s.bump_clause(c)
+					s.enqueue(learnt[0], c)
+				}
+				s.var_inc /= 0.95
+				s.cla_inc /= 0.999
+				if s.conflicts >= next_reduce {
+					s.reduce_learnts()
+					next_reduce = s.conflicts + reduce_first + reduce_step * (s.conflicts / reduce_first)
+				}
+				if s.conflicts % 256 == 0 && ctx.Err() != nil {
+					s.cancel_until(0)
+					return false, ctx.Err()
+				}
+				continue
+			}
+
+			v := s.order.pop_unassigned(s.assigns)
+			if v < 0 {
+				s.model = make([]bool, s.vars + 1)
+				for i := 0; i < s.vars; i++ {
+					s.model[i + 1] = s.assigns[i] == 1
+				}
+				s.cancel_until(0)
+				return true, nil
+			}
+			s.trail_lim = append(s.trail_lim, len(s.trail))
+			lit := 2 * v + 1
+			if s.phase[v] {
+				lit = 2 * v
+			}
+			s.enqueue(lit, nil)
+		}
+		s.cancel_until(0)
+	}
+}
+
+func (h *VarHeap) less(i, j int) bool {
+	return (*h.activity)[h.heap[i]] > (*h.activity)[h.heap[j]]
+}
+
+func (h *VarHeap) swap(i, j int) {
+	h.heap[i], h.heap[j] = h.heap[j], h.heap[i]
+	h.index[h.heap[i]], h.index[h.heap[j]] = i, j
+}
+
+func (h *VarHeap) up(i int) {
+	for i > 0 && h.less(i, (i - 1) / 2) {
+		h.swap(i, (i - 1) / 2)
+		i = (i - 1) / 2
+	}
+}
+
+func (h *VarHeap) down(i int) {
+	for {
+		child := 2 * i + 1
+		if child >= len(h.heap) {
+			return
+		}
+		if child + 1 < len(h.heap) && h.less(child + 1, child) {
+			child++
+		}
+		if !h.less(child, i) {
+			return
+		}
+		h.swap(i, child)
+		i = child
+	}
+}
+
+func (h *VarHeap) push(v int) {
+	h.heap = append(h.heap, v)
+	h.index[v] = len(h.heap) - 1
+	h.up(len(h.heap) - 1)
+}
+
+// most active unassigned variable, -1 when all are assigned
+func (h *VarHeap) pop_unassigned(assigns []int8) int {
+	for len(h.heap) > 0 {
+		v := h.heap[0]
+		h.swap(0, len(h.heap) - 1)
+		h.heap = h.heap[:len(h.heap) - 1]
+		h.index[v] = -1
+		h.down(0)
+		if assigns[v] == 0 {
+			return v
+		}
+	}
+	return -1
+}
+
+// ------------  input, output -----------
+
+func write_dimacs(w io.Writer, f *CNF) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "p cnf %d %d\n", f.vars, len(f.clauses))
+	for _, clause := range f.clauses {
+		for _, lit := range clause {
+			bw.WriteString(strconv.Itoa(lit))
+			bw.WriteByte(' ')
+		}
+		bw.WriteString("0\n")
+	}
+	return bw.Flush()
+}
+
+// model of an external solver output: "s SATISFIABLE" or "SAT" then the values in "v" lines
+// or on plain lines, return nil for an unsatisfiable formula
+func read_dimacs_model(rd io.Reader, vars int) ([]bool, error) {
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 1 << 20), 1 << 30)
+	model := make([]bool, vars + 1)
+	status := ""
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] == "c" {
+			continue
+		}
+		switch fields[0] {
+		case "s":
+			status = strings.Join(fields[1:], " ")
+			continue
+		case "SAT", "UNSAT":
+			status = fields[0]
+			continue
+		case "v":
+			fields = fields[1:]
+		}
+		for _, field := range fields {
+			lit, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid model value %q", field)
+			}
+			if lit > 0 && lit <= vars {
+				model[lit] = true
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	switch status {
+	case "SATISFIABLE", "SAT":
+		// the constant variable is true in every model
+		model[lit_true] = true
+		return model, nil
+	case "UNSATISFIABLE", "UNSAT":
+		return nil, nil
+	}
+	return nil, errors.New("Solver output has no SATISFIABLE or UNSATISFIABLE line")
+}
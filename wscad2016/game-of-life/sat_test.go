@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func random_cnf(rnd *rand.Rand, vars, clauses int) *CNF {
+	f := NewCNF()
+	for f.vars < vars + 1 {
+		f.new_var()
+	}
+	for i := 0; i < clauses; i++ {
+		var clause []int
+		for len(clause) < 3 {
+			lit := 2 + rnd.Intn(vars)
+			if rnd.Intn(2) == 0 {
+				lit = -lit
+			}
+			clause = append(clause, lit)
+		}
+		f.add(clause...)
+	}
+	return f
+}
+
+// every assignment of the variables past the constant one
+func brute_force_sat(f *CNF) bool {
+	model := make([]bool, f.vars + 1)
+	model[lit_true] = true
+	for bits := 0; bits < 1 << uint(f.vars - 1); bits++ {
+		for v := 2; v <= f.vars; v++ {
+			model[v] = bits & (1 << uint(v - 2)) != 0
+		}
+		if f.satisfied(model) {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_solver_random_3sat(t *testing.T) {
+	// near the threshold ratio of 4.26 about half the formulas are satisfiable
+	rnd := rand.New(rand.NewSource(1))
+	count := 0
+	for i := 0; i < 200; i++ {
+		f := random_cnf(rnd, 12, 51)
+		s := NewSolver(f)
+		sat, err := s.solve(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := brute_force_sat(f); sat != want {
+			t.Fatalf("formula %d: got %v, want %v", i, sat, want)
+		}
+		if sat && !f.satisfied(s.model) {
+			t.Fatalf("formula %d: model does not satisfy the clauses", i)
+		}
+		if sat {
+			count++
+		}
+	}
+	if count == 0 || count == 200 {
+		t.Errorf("got %d satisfiable formulas of 200", count)
+	}
+}
+
+func Test_solver_pigeonhole(t *testing.T) {
+	// 6 pigeons in 5 holes need conflicts and learnt clauses to refute
+	const pigeons, holes = 6, 5
+	f := NewCNF()
+	in := make([][]int, pigeons)
+	for p := range in {
+		in[p] = make([]int, holes)
+		for h := range in[p] {
+			in[p][h] = f.new_var()
+		}
+		f.add(in[p]...)
+	}
+	for h := 0; h < holes; h++ {
+		for p := 0; p < pigeons; p++ {
+			for q := p + 1; q < pigeons; q++ {
+				f.add(-in[p][h], -in[q][h])
+			}
+		}
+	}
+	s := NewSolver(f)
+	if sat, err := s.solve(context.Background()); sat || err != nil {
+		t.Errorf("got %v %v, want unsatisfiable", sat, err)
+	}
+	if s.conflicts == 0 {
+		t.Error("refuted without a conflict")
+	}
+}
+
+func Test_unary_count(t *testing.T) {
+	// at[j] of every assignment of 4 inputs, one of them a constant
+	f := NewCNF()
+	inputs := []int{f.new_var(), f.new_var(), lit_true, f.new_var()}
+	at := f.unary_count(inputs)
+	for bits := 0; bits < 8; bits++ {
+		g := &CNF{vars: f.vars, clauses: append([][]int(nil), f.clauses...)}
+		count := 1
+		for i, v := range []int{inputs[0], inputs[1], inputs[3]} {
+			if bits & (1 << uint(i)) != 0 {
+				g.add(v)
+				count++
+			} else {
+				g.add(-v)
+			}
+		}
+		s := NewSolver(g)
+		if sat, _ := s.solve(context.Background()); !sat {
+			t.Fatalf("inputs %03b: unsatisfiable", bits)
+		}
+		for j := range at {
+			if g.value(s.model, at[j]) != (count >= j) {
+				t.Errorf("inputs %03b: at least %d is %v", bits, j, g.value(s.model, at[j]))
+			}
+		}
+	}
+}
+
+func Test_dimacs(t *testing.T) {
+	f := NewCNF()
+	a, b := f.new_var(), f.new_var()
+	f.add(a, -b)
+	f.add(lit_false, b)
+	f.add(lit_true, -a)
+	buf := &bytes.Buffer{}
+	write_dimacs(buf, f)
+	if want := "p cnf 3 3\n1 0\n2 -3 0\n3 0\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	model, err := read_dimacs_model(strings.NewReader("c kissat\ns SATISFIABLE\nv -1 2\nv 3 0\n"), f.vars)
+	if err != nil || !f.satisfied(model) {
+		t.Errorf("got %v %v", model, err)
+	}
+	if model, err := read_dimacs_model(strings.NewReader("UNSAT\n"), f.vars); model != nil || err != nil {
+		t.Errorf("got %v %v, want unsatisfiable", model, err)
+	}
+	if _, err := read_dimacs_model(strings.NewReader("v 1 2 0\n"), f.vars); err == nil {
+		t.Error("expected error without a status line")
+	}
+}
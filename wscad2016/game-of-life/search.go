@@ -0,0 +1,279 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ------------------ Data type -----------
+
+// soups are soup_size x soup_size squares, half of the cells alive
+const soup_size = 16
+
+// a soup is settled when its population repeat with a period up to settle_period
+// for settle_window generations, checked every settle_window generations
+const settle_period = 30
+const settle_window = 120
+
+type SearchOptions struct {
+	radius      int // census cluster radius
+	max_period  int // generations an object is run to find its period
+	generations int // a soup not settled after this many generations is unsettled
+	rare        int // objects found in at most this many soups are rare, their seeds are kept
+	workers     int
+}
+
+func NewSearchOptions() SearchOptions {
+	return SearchOptions{radius: 2, max_period: census_period, generations: 10000, rare: 10, workers: 1}
+}
+
+// the objects one soup settled into
+type SoupResult struct {
+	seed        int64
+	objects     []Object
+	settled     bool
+	generations int
+}
+
+type SearchEntry struct {
+	object Object  // as found in the soup of the first seed
+	first  int64   // smallest seed of the soups with it
+	count  int     // objects in all the soups
+	soups  int     // soups with at least one
+	seeds  []int64 // smallest seeds of the soups with it, at most opts.rare of them
+}
+
+// Search is the census of many soups, the same seeds give the same search for
+// every number of workers
+type Search struct {
+	rule      Rule
+	opts      SearchOptions
+	first     int64
+	soups     int
+	objects   int
+	unsettled []int64 // smallest seeds of the soups that did not settle, at most opts.rare of them
+	missed    int     // unsettled soups
+	entries   map[string]*SearchEntry
+}
+
+// -------------------- problem solving functions ----------------------
+
+// soup of the seed, math/rand sequence is fixed for a seed
+func soup_board(seed int64, rule Rule) Board {
+	rnd := rand.New(rand.NewSource(seed))
+	board := NewBoard(soup_size, soup_size)
+	for i := 0; i < soup_size; i++ {
+		for k := 0; k < soup_size; k++ {
+			board.data[i][k] = set_life_status(rnd.Intn(2))
+		}
+	}
+	board.rule = rule
+	return board
+}
+
+// population of the last settle_window generations repeat with a period up to settle_period
+func settled(populations []int) bool {
+	last := len(populations) - 1
+	if last < settle_window + settle_period {
+		return false
+	}
+	for p := 1; p <= settle_period; p++ {
+		same := true
+		for g := last; g > last - settle_window && same; g-- {
+			same = populations[g] == populations[g - p]
+		}
+		if same {
+			return true
+		}
+	}
+	return false
+}
+
+// run the soup on the plane until it settle and classify what is left, the rule is checked by search_soups
+func run_soup(seed int64, rule Rule, opts SearchOptions, pool *WorkerPool) SoupResult {
+	s := NewSparse(rule)
+	soup := soup_board(seed, rule)
+	for i := 0; i < soup_size; i++ {
+		for k := 0; k < soup_size; k++ {
+			if board_alive(soup, i, k) {
+				s.set(i, k, true)
+			}
+		}
+	}
+	result := SoupResult{seed: seed}
+	populations := []int{s.population()}
+	for gen := 1; gen <= opts.generations; gen++ {
+		play_parallel_sparse(s, pool.in, pool.out)
+		populations = append(populations, s.population())
+		result.generations = gen
+		if populations[gen] == 0 || gen % settle_window == 0 && settled(populations) {
+			result.settled = true
+			break
+		}
+	}
+
+	for _, cluster := range cell_clusters(sparse_cells(s), opts.radius) {
+		result.objects = append(result.objects, classify_cluster(cluster, rule, opts.max_period, pool))
+	}
+	return result
+}
+
+// run the soups of seeds first to first+n-1 on workers goroutines, an interrupt stop
+// giving new soups, the search then cover the soups before the first one not done
+func search_soups(ctx context.Context, rule Rule, first int64, n int, opts SearchOptions) (*Search, error) {
+	if err := check_census_rule(rule); err != nil {
+		return nil, err
+	}
+	if n < 0 || opts.radius < 1 || opts.max_period < 1 || opts.generations < 1 || opts.rare < 0 || opts.workers < 1 {
+		return nil, errors.New("Search soups, radius, period, generations, rare and workers must be positive")
+	}
+
+	jobs := make(chan int64)
+	results := make(chan SoupResult)
+	var wg sync.WaitGroup
+	wg.Add(opts.workers)
+	for w := 0; w < opts.workers; w++ {
+		go func() {
+			defer wg.Done()
+			pool := NewWorkerPool(1)
+			defer pool.close()
+			for seed := range jobs {
+				results <- run_soup(seed, rule, opts, pool)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := 0; i < n; i++ {
+			select {
+			case jobs <- first + int64(i):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	s := &Search{rule: rule, opts: opts, first: first, entries: make(map[string]*SearchEntry)}
+	for result := range results {
+		s.add(result)
+	}
+	return s, ctx.Err()
+}
+
+// insert seed in the sorted seeds, keep the smallest limit of them
+func keep_seed(seeds []int64, seed int64, limit int) []int64 {
+	i := sort.Search(len(seeds), func(i int) bool { return seeds[i] >= seed })
+	if i >= limit {
+		return seeds
+	}
+	seeds = append(seeds, 0)
+	copy(seeds[i + 1:], seeds[i:])
+	seeds[i] = seed
+	if len(seeds) > limit {
+		seeds = seeds[:limit]
+	}
+	return seeds
+}
+
+func (s *Search) add(result SoupResult) {
+	s.soups++
+	if !result.settled {
+		s.missed++
+		s.unsettled = keep_seed(s.unsettled, result.seed, s.opts.rare)
+	}
+
+	// soups come in any order, the object of the smallest seed is kept
+	counts := make(map[string]int)
+	for _, o := range result.objects {
+		entry, ok := s.entries[o.key]
+		if !ok {
+			entry = &SearchEntry{first: result.seed}
+			s.entries[o.key] = entry
+		}
+		if counts[o.key] == 0 && (entry.soups == 0 || result.seed < entry.first) {
+			o.name = object_name(s.rule, o.key)
+			entry.object, entry.first = o, result.seed
+		}
+		counts[o.key]++
+		s.objects++
+	}
+	for key, count := range counts {
+		entry := s.entries[key]
+		entry.count += count
+		entry.soups++
+		entry.seeds = keep_seed(entry.seeds, result.seed, s.opts.rare)
+	}
+}
+
+// entries by count, most common first
+func (s *Search) sorted() []*SearchEntry {
+	entries := make([]*SearchEntry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		return entries[i].object.key < entries[j].object.key
+	})
+	return entries
+}
+
+// ------------  input, output -----------
+
+func format_seeds(seeds []int64) string {
+	strs := make([]string, len(seeds))
+	for i, seed := range seeds {
+		strs[i] = fmt.Sprint(seed)
+	}
+	return strings.Join(strs, " ")
+}
+
+// object table with the seeds of the rare objects, then the unsettled seeds
+func (s *Search) write(w io.Writer) error {
+	fmt.Fprintf(w, "search of %d soups from seed %d, rule %v, %d objects, %d soups not settled in %d generations\n",
+		s.soups, s.first, s.rule, s.objects, s.missed, s.opts.generations)
+	fmt.Fprintf(w, "%9s  %8s  %-16s %-11s %6s  %-16s %5s  %-24s  %s\n", "count", "soups", "name", "class", "period", "velocity", "cells", "key", "seeds")
+	for _, entry := range s.sorted() {
+		o := entry.object
+		name := o.name
+		if name == "" {
+			name = "-"
+		}
+		period := ""
+		if o.period > 1 {
+			period = fmt.Sprint(o.period)
+		}
+		// every seed of a rare object is kept
+		seeds := ""
+		if entry.soups <= s.opts.rare {
+			seeds = format_seeds(entry.seeds)
+		}
+		_, err := fmt.Fprintf(w, "%9d  %8d  %-16s %-11s %6s  %-16s %5d  %-24s  %s\n", entry.count, entry.soups, name, class_name[o.class],
+			period, o.velocity(), o.cells, o.key, seeds)
+		if err != nil {
+			return err
+		}
+	}
+	if s.missed > 0 {
+		if _, err := fmt.Fprintf(w, "not settled: %s\n", format_seeds(s.unsettled)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_soup_board(t *testing.T) {
+	a, b := soup_board(7, Conway), soup_board(7, Conway)
+	if !same_board(a, b) {
+		t.Error("same seed gave different soups")
+	}
+	if same_board(a, soup_board(8, Conway)) {
+		t.Error("seeds 7 and 8 gave the same soup")
+	}
+	if population := board_stats(a).population; population < 96 || population > 160 {
+		t.Errorf("got population %d, want about half of 256", population)
+	}
+}
+
+func Test_settled(t *testing.T) {
+	still := make([]int, settle_window + settle_period + 1)
+	period3 := make([]int, len(still))
+	growing := make([]int, len(still))
+	for i := range still {
+		still[i], period3[i], growing[i] = 40, 40 + i % 3, i
+	}
+	if !settled(still) || !settled(period3) || settled(growing) || settled(still[:settle_window]) {
+		t.Errorf("got still %v period 3 %v growing %v short %v", settled(still), settled(period3), settled(growing), settled(still[:settle_window]))
+	}
+}
+
+func Test_keep_seed(t *testing.T) {
+	var seeds []int64
+	for _, seed := range []int64{9, 3, 7, 1, 8, 2} {
+		seeds = keep_seed(seeds, seed, 4)
+	}
+	if !reflect.DeepEqual(seeds, []int64{1, 2, 3, 7}) {
+		t.Errorf("got %v", seeds)
+	}
+}
+
+func search_summary(s *Search) map[string]SearchEntry {
+	summary := make(map[string]SearchEntry)
+	for key, entry := range s.entries {
+		summary[key] = *entry
+	}
+	return summary
+}
+
+func Test_search_workers(t *testing.T) {
+	// the same soups give the same census on one or several workers
+	opts := NewSearchOptions()
+	opts.rare = 3
+	one, err := search_soups(context.Background(), Conway, 100, 12, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.workers = 3
+	three, err := search_soups(context.Background(), Conway, 100, 12, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if one.soups != 12 || one.objects != three.objects || one.missed != 0 || !reflect.DeepEqual(search_summary(one), search_summary(three)) {
+		t.Errorf("got %d soups %d and %d objects %d unsettled", one.soups, one.objects, three.objects, one.missed)
+	}
+	if one.entries["xs4_3.3"] == nil || one.entries["xs4_3.3"].object.name != "block" {
+		t.Error("no block in 12 soups")
+	}
+
+	// the seeds of a rare object give it back
+	for key, entry := range one.entries {
+		if entry.soups > opts.rare {
+			continue
+		}
+		pool := NewWorkerPool(1)
+		result := run_soup(entry.seeds[0], Conway, opts, pool)
+		pool.close()
+		found := false
+		for _, o := range result.objects {
+			found = found || o.key == key
+		}
+		if len(entry.seeds) != entry.soups || !found {
+			t.Errorf("%s: seeds %v, not found in soup %d", key, entry.seeds, entry.seeds[0])
+		}
+		break
+	}
+
+	buf := &bytes.Buffer{}
+	one.write(buf)
+	if !strings.HasPrefix(buf.String(), "search of 12 soups from seed 100, rule B3/S23") || !strings.Contains(buf.String(), "block") {
+		t.Errorf("got report:\n%s", buf.String())
+	}
+}
+
+func Test_search_interrupt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s, err := search_soups(ctx, Conway, 1, 1000, NewSearchOptions())
+	if err != context.Canceled || s == nil || s.soups >= 1000 {
+		t.Errorf("got %v %v", s, err)
+	}
+}
+
+func Test_search_unsupported(t *testing.T) {
+	rule, _ := parse_rule("B013/S23")
+	if _, err := search_soups(context.Background(), rule, 1, 10, NewSearchOptions()); err == nil {
+		t.Error("expected error for a B0 rule")
+	}
+	opts := NewSearchOptions()
+	opts.generations = 0
+	if _, err := search_soups(context.Background(), Conway, 1, 10, opts); err == nil {
+		t.Error("expected error for no generations")
+	}
+}
@@ -0,0 +1,294 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"errors"
+)
+
+// ------------------ Data type -----------
+
+// side of a square tile of the sparse universe
+const tile_size = 32
+
+// tile coordinate, cell [row,col] of the plane is in tile [row/tile_size, col/tile_size]
+type TileKey struct {
+	row, col int
+}
+
+type Tile struct {
+	cells      [tile_size][tile_size]byte // 0 dead, 1 alive
+	population int
+}
+
+// Sparse is an unbounded plane, only tiles with live cells are stored and
+// the map grow when a pattern move into a new tile
+type Sparse struct {
+	tiles      map[TileKey]*Tile
+	rule       Rule
+	generation int
+}
+
+// TileChunk is a tile with a one cell halo from the neighbour tiles
+type TileChunk struct {
+	key    TileKey
+	halo   [tile_size + 2][tile_size + 2]byte
+	empty  bool // no live cell in the halo, the tile stay empty without B0
+	result *Tile
+	rule   Rule
+}
+
+func NewSparse(rule Rule) *Sparse {
+	return &Sparse{tiles: make(map[TileKey]*Tile), rule: rule}
+}
+
+// floor division, -1/32 is tile -1
+func floor_div(a, b int) int {
+	q := a / b
+	if a % b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+func tile_of(row, col int) (TileKey, int, int) {
+	key := TileKey{floor_div(row, tile_size), floor_div(col, tile_size)}
+	return key, row - key.row * tile_size, col - key.col * tile_size
+}
+
+func (s *Sparse) set(row, col int, alive bool) {
+	key, r, c := tile_of(row, col)
+	tile, ok := s.tiles[key]
+	if !ok {
+		if !alive {
+			return
+		}
+		tile = &Tile{}
+		s.tiles[key] = tile
+	}
+
+	v := byte(0)
+	if alive {
+		v = 1
+	}
+	tile.population += int(v) - int(tile.cells[r][c])
+	tile.cells[r][c] = v
+	if tile.population == 0 {
+		delete(s.tiles, key)
+	}
+}
+
+func (s *Sparse) get(row, col int) bool {
+	key, r, c := tile_of(row, col)
+	tile, ok := s.tiles[key]
+	return ok && tile.cells[r][c] == 1
+}
+
+func (s *Sparse) population() int {
+	count := 0
+	for _, tile := range s.tiles {
+		count += tile.population
+	}
+	return count
+}
+
+// smallest rectangle [min_row, max_row] x [min_col, max_col] with all live cells,
+// ok is false for an empty universe
+func (s *Sparse) bounding_box() (min_row, min_col, max_row, max_col int, ok bool) {
+	for key, tile := range s.tiles {
+		for r := 0; r < tile_size; r++ {
+			for c := 0; c < tile_size; c++ {
+				if tile.cells[r][c] == 0 {
+					continue
+				}
+				row, col := key.row * tile_size + r, key.col * tile_size + c
+				if !ok {
+					min_row, min_col, max_row, max_col, ok = row, col, row, col, true
+					continue
+				}
+				if row < min_row {
+					min_row = row
+				}
+				if row > max_row {
+					max_row = row
+				}
+				if col < min_col {
+					min_col = col
+				}
+				if col > max_col {
+					max_col = col
+				}
+			}
+		}
+	}
+	return
+}
+
+// -------------------- problem solving functions ----------------------
+
+// load the board, cell [row,col] of the board is [row,col] of the plane
+func NewSparseFromBoard(b Board) (*Sparse, error) {
+	if b.rule.birth & 1 != 0 {
+		return nil, errors.New("Sparse universe does not support B0 rules")
+	}
+	if b.boundary != BoundaryDead {
+		return nil, errors.New("Sparse universe is unbounded, boundary must be dead")
+	}
+	if err := check_life_like("Sparse universe", b.rule); err != nil {
+		return nil, err
+	}
+
+	s := NewSparse(b.rule)
+	for i := 0; i < b.height; i++ {
+		for k := 0; k < b.width; k++ {
+			if board_alive(b, i, k) {
+				s.set(i, k, true)
+			}
+		}
+	}
+	return s, nil
+}
+
+// width x height window of the plane with top left corner at [row,col]
+func (s *Sparse) board(row, col, width, height int) Board {
+	b := NewBoard(width, height)
+	b.rule = s.rule
+	for i := 0; i < height; i++ {
+		for k := 0; k < width; k++ {
+			alive := 0
+			if s.get(row + i, col + k) {
+				alive = 1
+			}
+			b.data[i][k] = set_life_status(alive)
+		}
+	}
+	return b
+}
+
+func (tc TileChunk) play() Chunk {
+	tile := &Tile{}
+	if tc.empty {
+		tc.result = tile
+		return tc
+	}
+	// live cells of every halo column in the rows above, at and below r
+	var column [tile_size + 2]int
+	for r := 1; r <= tile_size; r++ {
+		for c := range column {
+			column[c] = int(tc.halo[r - 1][c]) + int(tc.halo[r][c]) + int(tc.halo[r + 1][c])
+		}
+		for c := 1; c <= tile_size; c++ {
+			count := column[c - 1] + column[c] + column[c + 1]
+			current := int(tc.halo[r][c])
+			alive := game_of_life_status(tc.rule, current, count - current)
+			tile.cells[r - 1][c - 1] = byte(alive)
+			tile.population += alive
+		}
+	}
+	tc.result = tile
+	return tc
+}
+
+// tiles with live cells and their neighbours, a pattern can only grow one cell per step
+func (s *Sparse) active_tiles() []TileKey {
+	seen := make(map[TileKey]bool)
+	active := []TileKey{}
+	for key := range s.tiles {
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				k := TileKey{key.row + dr, key.col + dc}
+				if !seen[k] {
+					seen[k] = true
+					active = append(active, k)
+				}
+			}
+		}
+	}
+	return active
+}
+
+// cells [from, to) of a neighbour tile at offset d that are in the halo
+func span(d int) [2]int {
+	switch d {
+	case -1:
+		return [2]int{tile_size - 1, tile_size}
+	case 1:
+		return [2]int{0, 1}
+	}
+	return [2]int{0, tile_size}
+}
+
+func split_tiles(s *Sparse, active []TileKey, data_in chan <- Chunk) {
+	for _, key := range active {
+		chunk := TileChunk{key: key, rule: s.rule, empty: true}
+
+		// copy the tile and the border cells of its eight neighbours
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				tile, ok := s.tiles[TileKey{key.row + dr, key.col + dc}]
+				if !ok {
+					continue
+				}
+				if dr == 0 && dc == 0 {
+					// stored tiles have live cells
+					for r := 0; r < tile_size; r++ {
+						copy(chunk.halo[r + 1][1:], tile.cells[r][:])
+					}
+					chunk.empty = false
+					continue
+				}
+				// only the last row or column of a neighbour above or left is in the halo
+				rows, cols := span(dr), span(dc)
+				for r := rows[0]; r < rows[1]; r++ {
+					hr := r + 1 + dr * tile_size
+					for c := cols[0]; c < cols[1]; c++ {
+						hc := c + 1 + dc * tile_size
+						chunk.halo[hr][hc] = tile.cells[r][c]
+						chunk.empty = chunk.empty && tile.cells[r][c] == 0
+					}
+				}
+			}
+		}
+
+		data_in <- chunk
+	}
+}
+
+func merge_tiles(total int, data_out <-chan Chunk) map[TileKey]*Tile {
+	tiles := make(map[TileKey]*Tile)
+	for i := 0; i < total; i++ {
+		data, ok := <-data_out
+		if ok {
+			item := data.(TileChunk)
+			// empty tiles are dropped, the universe shrink with the pattern
+			if item.result.population > 0 {
+				tiles[item.key] = item.result
+			}
+		}
+	}
+	return tiles
+}
+
+// one generation, active tiles are processed on the worker pool
+func play_parallel_sparse(s *Sparse, in chan <- Chunk, out <-chan Chunk) {
+	active := s.active_tiles()
+
+	go split_tiles(s, active, in)
+
+	s.tiles = merge_tiles(len(active), out)
+	s.generation++
+}
+
+// window with the initial width x height board and everything that grow out of it
+func sparse_window(s *Sparse, width, height int) Board {
+	min_row, min_col, max_row, max_col, ok := s.bounding_box()
+	if !ok {
+		return s.board(0, 0, width, height)
+	}
+
+	min_row, min_col = min(min_row, 0), min(min_col, 0)
+	max_row, max_col = max(max_row, height - 1), max(max_col, width - 1)
+	return s.board(min_row, min_col, max_col - min_col + 1, max_row - min_row + 1)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_sparse_match_rows(t *testing.T) {
+	pool := NewWorkerPool(4)
+	defer pool.close()
+	for _, str := range []string{"B3/S23", "B36/S23", "B2/S"} {
+		board := new_soup_board(80, 16, 11)
+		board.rule, _ = parse_rule(str)
+
+		s, err := NewSparseFromBoard(board)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 20; i++ {
+			play_parallel_sparse(s, pool.in, pool.out)
+		}
+
+		want := run_parallel(board, 20)
+		got := s.board(0, 0, board.width, board.height)
+		if strings.Join(board_rows(got), "\n") != strings.Join(board_rows(want), "\n") {
+			t.Errorf("%s: sparse differ from row engine", str)
+		}
+	}
+}
+
+func Test_sparse_glider_grow(t *testing.T) {
+	pool := NewWorkerPool(4)
+	defer pool.close()
+
+	// glider heading to the top left, out of the initial board into negative coordinates
+	p, _ := read_cells(strings.NewReader("OOO\nO..\n.O.\n"))
+	board, _ := place_pattern(p, 3, 3, 0, 0)
+	s, _ := NewSparseFromBoard(board)
+
+	min_row, min_col, max_row, max_col, _ := s.bounding_box()
+	for i := 0; i < 400; i++ {
+		play_parallel_sparse(s, pool.in, pool.out)
+	}
+
+	// 100 cells diagonal after 400 steps, same phase and shape
+	r0, c0, r1, c1, ok := s.bounding_box()
+	if !ok || s.population() != 5 || r0 != min_row - 100 || c0 != min_col - 100 || r1 != max_row - 100 || c1 != max_col - 100 {
+		t.Fatalf("got box %d,%d %d,%d population %d", r0, c0, r1, c1, s.population())
+	}
+	got := board_rows(s.board(r0, c0, 3, 3))
+	if strings.Join(got, "\n") != "xxx\nx  \n x " {
+		t.Errorf("got:\n%s", strings.Join(got, "\n"))
+	}
+
+	// only the tiles around the glider are kept
+	if len(s.tiles) > 4 {
+		t.Errorf("%d tiles for a single glider", len(s.tiles))
+	}
+}
+
+func Test_sparse_window(t *testing.T) {
+	s := NewSparse(Conway)
+	s.set(-3, 5, true)
+	s.set(2, -40, true)
+	if !s.get(-3, 5) || !s.get(2, -40) || s.get(0, 0) || s.population() != 2 {
+		t.Fatal("set/get on negative coordinates")
+	}
+
+	r0, c0, r1, c1, _ := s.bounding_box()
+	if r0 != -3 || c0 != -40 || r1 != 2 || c1 != 5 {
+		t.Errorf("box %d,%d %d,%d", r0, c0, r1, c1)
+	}
+
+	// window cover the box and the initial 4x4 board
+	b := sparse_window(s, 4, 4)
+	if b.width != 46 || b.height != 7 || b.data[0][45] != 'x' || b.data[5][0] != 'x' {
+		t.Errorf("window size %dx%d", b.width, b.height)
+	}
+
+	s.set(-3, 5, false)
+	s.set(2, -40, false)
+	if len(s.tiles) != 0 {
+		t.Error("empty tiles are not removed")
+	}
+}
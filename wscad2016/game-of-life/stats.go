@@ -0,0 +1,126 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ------------------ Data type -----------
+
+// StatsWriter write one line per generation as CSV or JSON Lines, a line after
+// generations skipped by a cycle jump has the number of skipped generations
+type StatsWriter struct {
+	w      *bufio.Writer
+	file   io.Closer // closed with the writer, nil if not owned
+	format string
+	header bool
+	last   int // generation of the last line, -1 before the first one
+	err    error // first write error
+}
+
+func NewStatsWriter(w io.Writer, format string) (*StatsWriter, error) {
+	if format != "csv" && format != "jsonl" {
+		return nil, errors.New("Invalid stats format: " + format)
+	}
+	return &StatsWriter{w: bufio.NewWriter(w), format: format, last: -1}, nil
+}
+
+// -------------------- problem solving functions ----------------------
+
+func (sw *StatsWriter) write(generation int, s Stats) error {
+	if sw.err != nil {
+		return sw.err
+	}
+	skipped := 0
+	if sw.last >= 0 && generation > sw.last + 1 {
+		skipped = generation - sw.last - 1
+	}
+	sw.last = generation
+	sw.err = sw.write_line(generation, s, skipped)
+	return sw.err
+}
+
+func (sw *StatsWriter) write_line(generation int, s Stats, skipped int) error {
+	empty := s.population == 0
+
+	if sw.format == "csv" {
+		if !sw.header {
+			fmt.Fprintln(sw.w, "generation,population,births,deaths,min_row,min_col,max_row,max_col,skipped")
+			sw.header = true
+		}
+		// empty board has no bounding box, the fields are left blank
+		if empty {
+			_, err := fmt.Fprintf(sw.w, "%d,%d,%d,%d,,,,,%d\n", generation, s.population, s.births, s.deaths, skipped)
+			return err
+		}
+		_, err := fmt.Fprintf(sw.w, "%d,%d,%d,%d,%d,%d,%d,%d,%d\n", generation, s.population, s.births, s.deaths,
+			s.min_row, s.min_col, s.max_row, s.max_col, skipped)
+		return err
+	}
+
+	box := "null"
+	if !empty {
+		box = fmt.Sprintf("{\"min_row\":%d,\"min_col\":%d,\"max_row\":%d,\"max_col\":%d}", s.min_row, s.min_col, s.max_row, s.max_col)
+	}
+	// only the line after a jump has the skipped generations
+	jump := ""
+	if skipped > 0 {
+		jump = fmt.Sprintf(",\"skipped\":%d", skipped)
+	}
+	_, err := fmt.Fprintf(sw.w, "{\"generation\":%d,\"population\":%d,\"births\":%d,\"deaths\":%d,\"bbox\":%s%s}\n",
+		generation, s.population, s.births, s.deaths, box, jump)
+	return err
+}
+
+// flush the lines and close the file, report the first write error
+func (sw *StatsWriter) close() error {
+	err := sw.w.Flush()
+	if sw.err != nil {
+		err = sw.err
+	}
+	if sw.file != nil {
+		if cerr := sw.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// create the statistics file and attach it to the engine
+func open_stats(e *Engine, path, format string) (*StatsWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	sw, err := NewStatsWriter(file, format)
+	if err == nil {
+		sw.file = file
+		err = e.WriteStats(sw)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return sw, nil
+}
+
+// write the stats of every generation the engine play, starting with the current one
+func (e *Engine) WriteStats(sw *StatsWriter) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := sw.write(e.generation, e.stats()); err != nil {
+		return err
+	}
+	e.observers = append(e.observers, func(generation int, s Stats) {
+		sw.write(generation, s)
+	})
+	return nil
+}
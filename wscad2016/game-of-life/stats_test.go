@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// births and deaths between two boards, bounding box of the second one
+func reference_stats(prev, next Board) Stats {
+	s := board_stats(next)
+	for i := 0; i < next.height; i++ {
+		for k := 0; k < next.width; k++ {
+			was, is := board_alive(prev, i, k), board_alive(next, i, k)
+			if is && !was {
+				s.births++
+			}
+			if was && !is {
+				s.deaths++
+			}
+		}
+	}
+	return s
+}
+
+func Test_stats_match_reference(t *testing.T) {
+	for _, kernel := range []string{"byte", "bit", "table"} {
+		board := new_soup_board(70, 20, 3)
+		e := new_test_engine(t, board, kernel)
+
+		var got []Stats
+		e.observers = append(e.observers, func(generation int, s Stats) {
+			got = append(got, s)
+		})
+		e.Step(30)
+
+		prev := board
+		for g := 0; g < 30; g++ {
+			next := run_parallel(prev, 1)
+			want := reference_stats(prev, next)
+			s := got[g]
+			s.hash, want.hash = 0, 0
+			if s != want {
+				t.Fatalf("%s generation %d: got %+v, want %+v", kernel, g + 1, s, want)
+			}
+			prev = next
+		}
+	}
+}
+
+func Test_stats_writer(t *testing.T) {
+	board := new_test_board(t, []string{"", "  x", "  x", "  x"})
+	e := new_test_engine(t, board, "byte")
+
+	var csv, jsonl bytes.Buffer
+	csv_writer, _ := NewStatsWriter(&csv, "csv")
+	jsonl_writer, _ := NewStatsWriter(&jsonl, "jsonl")
+	e.WriteStats(csv_writer)
+	e.WriteStats(jsonl_writer)
+	e.Step(2)
+	csv_writer.close()
+	jsonl_writer.close()
+
+	want := "generation,population,births,deaths,min_row,min_col,max_row,max_col,skipped\n" +
+		"0,3,0,0,1,2,3,2,0\n" +
+		"1,3,2,2,2,1,2,3,0\n" +
+		"2,3,2,2,1,2,3,2,0\n"
+	if csv.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", csv.String(), want)
+	}
+
+	lines := strings.Split(strings.TrimSpace(jsonl.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d json lines", len(lines))
+	}
+	var line struct {
+		Generation int
+		Population int
+		Births     int
+		Bbox       map[string]int
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line.Generation != 1 || line.Population != 3 || line.Births != 2 || line.Bbox["min_col"] != 1 {
+		t.Errorf("got %+v", line)
+	}
+
+	if _, err := NewStatsWriter(&csv, "xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func Test_stats_empty(t *testing.T) {
+	var buf bytes.Buffer
+	sw, _ := NewStatsWriter(&buf, "jsonl")
+	sw.write(4, NewStats())
+	sw.close()
+
+	if buf.String() != "{\"generation\":4,\"population\":0,\"births\":0,\"deaths\":0,\"bbox\":null}\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
@@ -0,0 +1,247 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ------------------ Data type -----------
+
+// halo exchange directions, opposite direction is d ^ 1
+const (
+	dir_n = iota
+	dir_s
+	dir_w
+	dir_e
+	dir_nw
+	dir_se
+	dir_ne
+	dir_sw
+)
+
+var dir_offset = [8][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}, {-1, -1}, {1, 1}, {-1, 1}, {1, -1}}
+
+// TileWorker own a rectangle of the board for the whole run, cells keep a one
+// cell halo around the tile that is refreshed from the neighbours every step
+type TileWorker struct {
+	row0, col0 int // top left cell of the tile on the board
+	rows, cols int
+	cells      [][]byte // (rows + 2) x (cols + 2), 0 dead, 1 alive
+	next       [][]byte
+	in         [8]chan []byte // border of the neighbour in direction d, nil at a dead edge
+	out        [8]chan []byte // own border for the neighbour in direction d
+	rule       Rule
+}
+
+// Tiled is a board split in tile_rows x tile_cols tiles, one goroutine per tile
+type Tiled struct {
+	workers  []*TileWorker
+	cmd      []chan int // number of steps to run
+	done     chan bool
+	width    int
+	height   int
+	boundary Boundary
+	rule     Rule
+}
+
+func new_cells(rows, cols int) [][]byte {
+	cells := make([][]byte, rows + 2)
+	for i := range cells {
+		cells[i] = make([]byte, cols + 2)
+	}
+	return cells
+}
+
+// parse tile shape "rows x cols", eg: 64x64 or 16x1024
+func parse_tile(str string) (int, int, error) {
+	var rows, cols int
+	if _, err := fmt.Sscanf(str, "%dx%d", &rows, &cols); err != nil || rows <= 0 || cols <= 0 {
+		return 0, 0, errors.New("Invalid tile shape: " + str)
+	}
+	return rows, cols, nil
+}
+
+// -------------------- problem solving functions ----------------------
+
+// own border toward direction d
+func (w *TileWorker) border(d int) []byte {
+	switch d {
+	case dir_n:
+		return append([]byte(nil), w.cells[1][1:w.cols + 1]...)
+	case dir_s:
+		return append([]byte(nil), w.cells[w.rows][1:w.cols + 1]...)
+	case dir_w, dir_e:
+		c := 1
+		if d == dir_e {
+			c = w.cols
+		}
+		col := make([]byte, w.rows)
+		for r := 0; r < w.rows; r++ {
+			col[r] = w.cells[r + 1][c]
+		}
+		return col
+	case dir_nw:
+		return []byte{w.cells[1][1]}
+	case dir_ne:
+		return []byte{w.cells[1][w.cols]}
+	case dir_sw:
+		return []byte{w.cells[w.rows][1]}
+	}
+	return []byte{w.cells[w.rows][w.cols]}
+}
+
+// write the border of the neighbour in direction d into the halo
+func (w *TileWorker) set_halo(d int, border []byte) {
+	switch d {
+	case dir_n:
+		copy(w.cells[0][1:], border)
+	case dir_s:
+		copy(w.cells[w.rows + 1][1:], border)
+	case dir_w, dir_e:
+		c := 0
+		if d == dir_e {
+			c = w.cols + 1
+		}
+		for r := 0; r < w.rows; r++ {
+			w.cells[r + 1][c] = border[r]
+		}
+	case dir_nw:
+		w.cells[0][0] = border[0]
+	case dir_ne:
+		w.cells[0][w.cols + 1] = border[0]
+	case dir_sw:
+		w.cells[w.rows + 1][0] = border[0]
+	case dir_se:
+		w.cells[w.rows + 1][w.cols + 1] = border[0]
+	}
+}
+
+// send the own border to all neighbours, then wait for theirs
+func (w *TileWorker) exchange() {
+	for d := 0; d < 8; d++ {
+		if w.out[d] != nil {
+			w.out[d] <- w.border(d)
+		}
+	}
+	for d := 0; d < 8; d++ {
+		if w.in[d] != nil {
+			w.set_halo(d, <-w.in[d])
+		}
+	}
+}
+
+// next generation of the tile, halo must be up to date
+func (w *TileWorker) compute() {
+	for r := 1; r <= w.rows; r++ {
+		top, value, bottom := w.cells[r - 1], w.cells[r], w.cells[r + 1]
+		result := w.next[r]
+
+		// sliding sum of the 3 cell columns, same idea as RowChunk.play
+		left := 0
+		mid := int(top[0] + value[0] + bottom[0])
+		right := int(top[1] + value[1] + bottom[1])
+		for c := 1; c <= w.cols; c++ {
+			left, mid, right = mid, right, int(top[c + 1] + value[c + 1] + bottom[c + 1])
+			current := int(value[c])
+			result[c] = byte(game_of_life_status(w.rule, current, left + mid + right - current))
+		}
+	}
+	w.cells, w.next = w.next, w.cells
+}
+
+func (w *TileWorker) run(cmd <-chan int, done chan<- bool) {
+	for n := range cmd {
+		for g := 0; g < n; g++ {
+			w.exchange()
+			w.compute()
+		}
+		done <- true
+	}
+}
+
+// split the board in tiles and start one worker per tile
+func NewTiled(b Board, tile_rows, tile_cols int) *Tiled {
+	grid_rows := (b.height + tile_rows - 1) / tile_rows
+	grid_cols := (b.width + tile_cols - 1) / tile_cols
+
+	t := &Tiled{width: b.width, height: b.height, boundary: b.boundary, rule: b.rule, done: make(chan bool)}
+	grid := make([][]*TileWorker, grid_rows)
+	for gr := 0; gr < grid_rows; gr++ {
+		grid[gr] = make([]*TileWorker, grid_cols)
+		for gc := 0; gc < grid_cols; gc++ {
+			w := &TileWorker{row0: gr * tile_rows, col0: gc * tile_cols, rule: b.rule}
+			w.rows = min(tile_rows, b.height - w.row0)
+			w.cols = min(tile_cols, b.width - w.col0)
+			w.cells, w.next = new_cells(w.rows, w.cols), new_cells(w.rows, w.cols)
+			for r := 0; r < w.rows; r++ {
+				for c := 0; c < w.cols; c++ {
+					if board_alive(b, w.row0 + r, w.col0 + c) {
+						w.cells[r + 1][c + 1] = 1
+					}
+				}
+			}
+			grid[gr][gc] = w
+			t.workers = append(t.workers, w)
+		}
+	}
+
+	// connect the neighbours, one buffered channel per direction
+	for gr := 0; gr < grid_rows; gr++ {
+		for gc := 0; gc < grid_cols; gc++ {
+			w := grid[gr][gc]
+			for d := 0; d < 8; d++ {
+				nr, nc := gr + dir_offset[d][0], gc + dir_offset[d][1]
+				if b.boundary == BoundaryWrap {
+					nr, nc = (nr + grid_rows) % grid_rows, (nc + grid_cols) % grid_cols
+				} else if nr < 0 || nr >= grid_rows || nc < 0 || nc >= grid_cols {
+					continue
+				}
+				ch := make(chan []byte, 1)
+				w.out[d] = ch
+				grid[nr][nc].in[d ^ 1] = ch
+			}
+		}
+	}
+
+	for _, w := range t.workers {
+		cmd := make(chan int)
+		t.cmd = append(t.cmd, cmd)
+		go w.run(cmd, t.done)
+	}
+	return t
+}
+
+// run n generations on all tiles
+func (t *Tiled) step(n int) {
+	for _, cmd := range t.cmd {
+		cmd <- n
+	}
+	for range t.cmd {
+		<-t.done
+	}
+}
+
+// gather the tiles into a board, workers must be idle
+func (t *Tiled) board() Board {
+	b := NewBoard(t.width, t.height)
+	b.boundary, b.rule = t.boundary, t.rule
+	for _, w := range t.workers {
+		for r := 0; r < w.rows; r++ {
+			for c := 0; c < w.cols; c++ {
+				b.data[w.row0 + r][w.col0 + c] = set_life_status(int(w.cells[r + 1][c + 1]))
+			}
+		}
+	}
+	return b
+}
+
+// stop the worker goroutines
+func (t *Tiled) close() {
+	for _, cmd := range t.cmd {
+		close(cmd)
+	}
+}
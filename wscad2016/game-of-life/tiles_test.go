@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_parse_tile(t *testing.T) {
+	if rows, cols, err := parse_tile("16x1024"); err != nil || rows != 16 || cols != 1024 {
+		t.Error(rows, cols, err)
+	}
+	for _, str := range []string{"", "16", "0x4", "4x-1", "axb"} {
+		if _, _, err := parse_tile(str); err == nil {
+			t.Errorf("%q: expected error", str)
+		}
+	}
+}
+
+func Test_tiles_match_seq(t *testing.T) {
+	for _, mode := range []string{"dead", "wrap"} {
+		board := new_random_board(50, 50, 5)
+		board.boundary, _ = parse_boundary(mode)
+		want := strings.Join(run_seq(t, board, 30, "-boundary", mode), "\n")
+
+		for _, shape := range []string{"7x9", "1x50", "50x1", "16x16", "64x64", "25x50"} {
+			rows, cols, _ := parse_tile(shape)
+			tiled := NewTiled(board, rows, cols)
+			tiled.step(10)
+			tiled.step(20)
+			got := strings.Join(board_rows(tiled.board()), "\n")
+			tiled.close()
+
+			if got != want {
+				t.Errorf("%s boundary, tile %s: differ from life_seq.go", mode, shape)
+			}
+		}
+	}
+}
+
+func Test_tiles_rule(t *testing.T) {
+	board := new_random_board(40, 31, 9)
+	board.rule, _ = parse_rule("B36/S23")
+
+	tiled := NewTiled(board, 8, 13)
+	tiled.step(15)
+	got := strings.Join(board_rows(tiled.board()), "\n")
+	tiled.close()
+
+	if got != strings.Join(board_rows(run_parallel(board, 15)), "\n") {
+		t.Error("tiles differ from row engine for B36/S23")
+	}
+}
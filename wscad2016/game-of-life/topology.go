@@ -0,0 +1,268 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ------------------ Data type -----------
+
+// Topology is the grid the board rows are laid on, part of the rule and written as its suffix
+type Topology int
+
+const (
+	// 8 neighbours, the row kernel
+	TopologySquare Topology = iota
+	// 6 neighbours, odd rows are shifted half a cell to the right, suffix H
+	TopologyHex
+	// 12 neighbours sharing an edge or a corner, the cell is an up triangle when row + col is even, suffix L
+	TopologyTri
+)
+
+// neighbour {row, col} offsets of every topology by cell parity
+var topology_offsets = [3][2][][2]int{
+	TopologySquare: {
+		{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}},
+		{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}},
+	},
+	TopologyHex: {
+		// even row
+		{{-1, -1}, {-1, 0}, {0, -1}, {0, 1}, {1, -1}, {1, 0}},
+		// odd row
+		{{-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, 0}, {1, 1}},
+	},
+	TopologyTri: {
+		// up triangle, the base is on the row below
+		{{-1, -1}, {-1, 0}, {-1, 1}, {0, -2}, {0, -1}, {0, 1}, {0, 2}, {1, -2}, {1, -1}, {1, 0}, {1, 1}, {1, 2}},
+		// down triangle, the base is on the row above
+		{{-1, -2}, {-1, -1}, {-1, 0}, {-1, 1}, {-1, 2}, {0, -2}, {0, -1}, {0, 1}, {0, 2}, {1, -1}, {1, 0}, {1, 1}},
+	},
+}
+
+// -------------------- problem solving functions ----------------------
+
+// 0 or 1, select the neighbour offsets of the cell
+func (t Topology) parity(row, col int) int {
+	switch t {
+	case TopologyHex:
+		return row & 1
+	case TopologyTri:
+		return (row + col) & 1
+	}
+	return 0
+}
+
+func (t Topology) neighbours(parity int) [][2]int {
+	return topology_offsets[t][parity]
+}
+
+func (t Topology) max_neighbours() int {
+	return len(topology_offsets[t][0])
+}
+
+// columns a change reach on the rows around it
+func (t Topology) reach() int {
+	if t == TopologyTri {
+		return 2
+	}
+	return 1
+}
+
+func (t Topology) suffix() string {
+	switch t {
+	case TopologyHex:
+		return "H"
+	case TopologyTri:
+		return "L"
+	}
+	return ""
+}
+
+func (t Topology) String() string {
+	switch t {
+	case TopologyHex:
+		return "hexagonal"
+	case TopologyTri:
+		return "triangular"
+	}
+	return "square"
+}
+
+// topology of a rule suffix, the rest of the rule
+func parse_topology_suffix(str string) (Topology, string) {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return TopologySquare, str
+	}
+	switch str[len(str) - 1] {
+	case 'H', 'h':
+		return TopologyHex, str[:len(str) - 1]
+	case 'L', 'l':
+		return TopologyTri, str[:len(str) - 1]
+	}
+	return TopologySquare, str
+}
+
+// neighbour count digit of a rule, a b c are 10 11 12 of the triangular grid
+func count_digit(n int) string {
+	if n < 10 {
+		return fmt.Sprint(n)
+	}
+	return string(rune('a' + n - 10))
+}
+
+func parse_count_digit(c rune) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'A' && c <= 'C':
+		return int(c - 'A') + 10
+	}
+	return -1
+}
+
+// a torus keep the parity of the cells across the edges
+func check_topology(b Board) error {
+	t := b.rule.topology
+	if b.boundary != BoundaryWrap {
+		return nil
+	}
+	if t == TopologyHex && b.height % 2 != 0 {
+		return fmt.Errorf("Hexagonal torus need an even height, got %d", b.height)
+	}
+	if t == TopologyTri && (b.height % 2 != 0 || b.width % 2 != 0) {
+		return fmt.Errorf("Triangular torus need an even width and height, got %dx%d", b.width, b.height)
+	}
+	return nil
+}
+
+// the row kernel over the neighbour offsets of the topology, square too, only the active columns when set
+func (rc RowChunk) play_topology() Chunk {
+	t := rc.rule.topology
+	size := len(rc.value)
+	rows := [3][]byte{rc.top, rc.value, rc.bottom}
+	wrap := rc.boundary == BoundaryWrap
+	copy(rc.result, rc.value)
+	rc.changed = nil
+	rc.births, rc.deaths = 0, 0
+
+	play := func(k int) {
+		neighbour := 0
+		for _, o := range t.neighbours(t.parity(rc.row_id, k)) {
+			col := k + o[1]
+			if col < 0 || col >= size {
+				if !wrap {
+					continue
+				}
+				col = (col + size) % size
+			}
+			if rows[o[0] + 1][col] == 'x' {
+				neighbour++
+			}
+		}
+		rc.update(k, neighbour)
+	}
+
+	if rc.columns == nil {
+		for k := 0; k < size; k++ {
+			play(k)
+		}
+	} else {
+		for_each_bit(rc.columns, play)
+	}
+
+	rc.summarize()
+	return rc
+}
+
+// ------------  input, output -----------
+
+// header like the text input, then one line per row:
+//	hexagonal   cells separated by a space, odd rows indented by one space, '.' is dead
+//	triangular  one character per cell, a dead cell show its shape, '^' up or 'v' down
+// 'x' is alive and the letters are the dying states of a Generations rule
+func read_topology_text(rd io.Reader, t Topology) (Board, int, error) {
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(nil, 1 << 26)
+
+	var width, height, step int
+	if !scanner.Scan() {
+		return Board{}, 0, errors.New("Invalid parameter")
+	}
+	var err error
+	if line := scanner.Text(); len(strings.Fields(line)) == 3 {
+		_, err = fmt.Sscanf(line, "%d %d %d", &width, &height, &step)
+	} else {
+		_, err = fmt.Sscanf(line, "%d %d", &width, &step)
+		height = width
+	}
+	if err != nil || width < 0 || height < 0 {
+		return Board{}, 0, errors.New("Invalid parameter")
+	}
+
+	board := NewBoard(width, height)
+	for i := 0; i < height && scanner.Scan(); i++ {
+		line := scanner.Text()
+		if t == TopologyHex {
+			line = strings.ReplaceAll(line, " ", "")
+		}
+		for k := 0; k < len(line) && k < width; k++ {
+			switch c := line[k]; {
+			case c == '.' || c == ' ' || c == '^' || c == 'v':
+				board.data[i][k] = ' '
+			case c == 'x' || cell_state(c) > 1:
+				board.data[i][k] = c
+			default:
+				return Board{}, 0, fmt.Errorf("Invalid %v cell %q on row %d", t, c, i)
+			}
+		}
+		for k := len(line); k < width; k++ {
+			board.data[i][k] = ' '
+		}
+	}
+	return board, step, scanner.Err()
+}
+
+func write_topology_text(w io.Writer, b Board) error {
+	t := b.rule.topology
+	bw := bufio.NewWriter(w)
+	line := make([]byte, 0, 2 * b.width + 1)
+	for i := 0; i < b.height; i++ {
+		line = line[:0]
+		if t == TopologyHex && i % 2 == 1 {
+			line = append(line, ' ')
+		}
+		for k := 0; k < b.width; k++ {
+			c := byte(' ')
+			if k < len(b.data[i]) {
+				c = b.data[i][k]
+			}
+			if cell_state(c) == 0 {
+				switch {
+				case t == TopologyHex:
+					c = '.'
+				case t.parity(i, k) == 0:
+					c = '^'
+				default:
+					c = 'v'
+				}
+			}
+			line = append(line, c)
+			if t == TopologyHex && k + 1 < b.width {
+				line = append(line, ' ')
+			}
+		}
+		line = append(line, '\n')
+		if _, err := bw.Write(line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
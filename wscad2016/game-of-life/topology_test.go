@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_parse_topology_rule(t *testing.T) {
+	for str, want := range map[string]Topology{"B2/S34H": TopologyHex, "B4/S345L": TopologyTri, "345/2/4H": TopologyHex, "B3/S23": TopologySquare} {
+		rule, err := parse_rule(str)
+		if err != nil || rule.topology != want || rule.String() != str {
+			t.Errorf("%s: got %v %q %v", str, rule.topology, rule.String(), err)
+		}
+	}
+	// counts 10 to 12 of the triangular grid
+	rule, err := parse_rule("B4a/S3bcL")
+	if err != nil || rule.birth != 1 << 4 | 1 << 10 || rule.survive != 1 << 3 | 1 << 11 | 1 << 12 || rule.String() != "B4a/S3bcL" {
+		t.Errorf("got %+v %q %v", rule, rule.String(), err)
+	}
+	// counts past the neighbours of the grid
+	for _, str := range []string{"B7/S34H", "B9/S23", "B3/SaL3", "B3/S2a"} {
+		if _, err := parse_rule(str); err == nil {
+			t.Errorf("%q: expected error", str)
+		}
+	}
+}
+
+// hexagonal neighbours from cube coordinates of the odd row shifted layout, independent of the offsets
+func hex_adjacent(r1, c1, r2, c2 int) bool {
+	q1, q2 := c1 - (r1 - (r1 & 1)) / 2, c2 - (r2 - (r2 & 1)) / 2
+	dq, dr := q2 - q1, r2 - r1
+	ds := -dq - dr
+	return max(abs_int(dq), abs_int(dr), abs_int(ds)) == 1
+}
+
+// triangles sharing an edge or a corner, vertices on a lattice of half cells
+func tri_adjacent(r1, c1, r2, c2 int) bool {
+	vertices := func(r, c int) [3][2]int {
+		if (r + c) & 1 == 0 {
+			return [3][2]int{{c + 1, r}, {c, r + 1}, {c + 2, r + 1}}
+		}
+		return [3][2]int{{c, r}, {c + 2, r}, {c + 1, r + 1}}
+	}
+	if r1 == r2 && c1 == c2 {
+		return false
+	}
+	for _, a := range vertices(r1, c1) {
+		for _, b := range vertices(r2, c2) {
+			if a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func play_topology_reference(b Board, adjacent func(r1, c1, r2, c2 int) bool) Board {
+	dst := NewBoard(b.width, b.height)
+	dst.boundary, dst.rule = b.boundary, b.rule
+	for r := 0; r < b.height; r++ {
+		for c := 0; c < b.width; c++ {
+			n := 0
+			for dr := -1; dr <= 1; dr++ {
+				for dc := -3; dc <= 3; dc++ {
+					// unwrapped position decide the shape, the wrapped one the cell
+					if !adjacent(r, c, r + dr, c + dc) {
+						continue
+					}
+					rr, cc := r + dr, c + dc
+					if b.boundary == BoundaryWrap {
+						rr, cc = (rr + b.height) % b.height, (cc + b.width) % b.width
+					}
+					if rr >= 0 && rr < b.height && cc >= 0 && cc < b.width && b.data[rr][cc] == 'x' {
+						n++
+					}
+				}
+			}
+			current := 0
+			if b.data[r][c] == 'x' {
+				current = 1
+			}
+			dst.data[r][c] = set_life_status(game_of_life_status(b.rule, current, n))
+		}
+	}
+	return dst
+}
+
+func Test_topology_match_reference(t *testing.T) {
+	cases := []struct {
+		rule     string
+		adjacent func(r1, c1, r2, c2 int) bool
+	}{
+		{"B2/S34H", hex_adjacent},
+		{"B24/S3H", hex_adjacent},
+		{"B45/S3456L", tri_adjacent},
+		{"B4/S345aL", tri_adjacent},
+	}
+	for _, tc := range cases {
+		for _, mode := range []Boundary{BoundaryDead, BoundaryWrap} {
+			board := new_random_board(70, 20, 3)
+			board.rule, _ = parse_rule(tc.rule)
+			board.boundary = mode
+
+			want := board
+			for i := 0; i < 30; i++ {
+				want = play_topology_reference(want, tc.adjacent)
+			}
+			if got := run_parallel(board, 30); !same_board(got, want) {
+				t.Errorf("%s boundary %d: got\n%s\nwant\n%s", tc.rule, mode,
+					strings.Join(board_rows(got), "\n"), strings.Join(board_rows(want), "\n"))
+			}
+			// the active columns of a triangle reach two columns
+			if got, full := run_parallel(board, 30), run_parallel_full(board, 30); !same_board(got, full) || got.stats != full.stats {
+				t.Errorf("%s boundary %d: dirty rows differ from full play", tc.rule, mode)
+			}
+		}
+	}
+}
+
+func new_hex_board(t *testing.T, width, height int, cells [][2]int) Board {
+	board := NewBoard(width, height)
+	board.rule, _ = parse_rule("B2/S34H")
+	for i := range board.data {
+		copy(board.data[i], strings.Repeat(" ", width))
+	}
+	for _, cell := range cells {
+		board.data[cell[0]][cell[1]] = 'x'
+	}
+	return board
+}
+
+func Test_hex_oscillators(t *testing.T) {
+	// B2/S34H: the cells of a domino die with one neighbour and the two cells next to both are born,
+	// a triangle of three cells turn into the three cells next to two of them and back
+	cases := []struct {
+		name         string
+		phase, other [][2]int
+	}{
+		{"domino", [][2]int{{2, 2}, {2, 3}}, [][2]int{{1, 2}, {3, 2}}},
+		{"triangle", [][2]int{{2, 2}, {2, 3}, {3, 2}}, [][2]int{{1, 2}, {3, 1}, {3, 3}}},
+	}
+	for _, tc := range cases {
+		board := new_hex_board(t, 6, 6, tc.phase)
+		if got := run_parallel(board, 1); !same_board(got, new_hex_board(t, 6, 6, tc.other)) {
+			t.Errorf("%s: generation 1 got\n%s", tc.name, strings.Join(board_rows(got), "\n"))
+		}
+		if got := run_parallel(board, 10); !same_board(got, board) {
+			t.Errorf("%s: generation 10 got\n%s", tc.name, strings.Join(board_rows(got), "\n"))
+		}
+
+		// cycle detection find period 2
+		e := new_test_engine(t, board, "byte")
+		if cycle, _ := e.Run(100, 4); cycle == nil || cycle.period != 2 {
+			t.Errorf("%s: got cycle %v, want period 2", tc.name, cycle)
+		}
+	}
+}
+
+func Test_topology_square_generic(t *testing.T) {
+	// the generic kernel with the square offsets give the same row as the 3x3 kernel
+	board := new_random_board(50, 3, 7)
+	for _, mode := range []Boundary{BoundaryDead, BoundaryWrap} {
+		rc := NewRowChunk(1, 50)
+		rc.boundary, rc.rule = mode, Conway
+		copy(rc.top, board.data[0])
+		copy(rc.value, board.data[1])
+		copy(rc.bottom, board.data[2])
+
+		fast := rc.play().(RowChunk)
+		rc.result = make([]byte, 50)
+		generic := rc.play_topology().(RowChunk)
+		if string(fast.result) != string(generic.result) || fast.hash != generic.hash || fast.births != generic.births {
+			t.Errorf("boundary %d: got %q, want %q", mode, generic.result, fast.result)
+		}
+	}
+}
+
+func Test_topology_text(t *testing.T) {
+	for _, tc := range []struct {
+		rule, text string
+	}{
+		{"B2/S34H", "x . .\n . x .\n"},
+		{"B4/S345L", "xv^\nvxv\n"},
+		{"/2/3H", "x . B\n . x .\n"},
+	} {
+		board, _, _ := read_input(strings.NewReader("3 2 0\nx  \n x \n"))
+		board.rule, _ = parse_rule(tc.rule)
+		if board.rule.dying > 0 {
+			board.data[0][2] = 'B'
+		}
+
+		buf := &bytes.Buffer{}
+		if err := write_board(buf, board, "text"); err != nil || buf.String() != tc.text {
+			t.Errorf("%s: got %q %v, want %q", tc.rule, buf.String(), err, tc.text)
+		}
+		got, step, err := read_topology_text(strings.NewReader("3 2 5\n" + tc.text), board.rule.topology)
+		got.rule = board.rule
+		if err != nil || step != 5 || !same_board(got, board) {
+			t.Errorf("%s: read back %q %v", tc.rule, board_rows(got), err)
+		}
+	}
+	if _, _, err := read_topology_text(strings.NewReader("3 1 0\nx o .\n"), TopologyHex); err == nil {
+		t.Error("expected error for an unknown cell")
+	}
+}
+
+func Test_topology_check(t *testing.T) {
+	board := new_hex_board(t, 6, 5, nil)
+	board.boundary = BoundaryWrap
+	if _, err := NewEngine(board, "byte", 2); err == nil {
+		t.Error("expected error for a hexagonal torus of odd height")
+	}
+	board = new_hex_board(t, 6, 6, nil)
+	if _, err := NewEngine(board, "bit", 2); err == nil {
+		t.Error("expected error for the bit kernel")
+	}
+	if err := write_board(&bytes.Buffer{}, board, "rle"); err == nil {
+		t.Error("expected error for RLE output")
+	}
+}
+
+func Test_topology_cluster(t *testing.T) {
+	// bands start on odd rows, the cells keep the shape of their board row
+	for _, str := range []string{"B2/S34H", "B45/S3456L"} {
+		board := new_random_board(30, 22, 5)
+		board.rule, _ = parse_rule(str)
+		board.boundary = BoundaryWrap
+		got, err := run_cluster(t, board, 3, 15)
+		if err != nil || !same_board(got, run_parallel(board, 15)) {
+			t.Errorf("%s: cluster differ from the rows engine %v", str, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/**
+	Author: Nikson Kanti Paul
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Wireworld models digital circuits with four states:
+//	0 empty, 1 electron head, 2 electron tail, 3 conductor
+// a head become a tail, a tail become a conductor and a conductor become a head when one or
+// two of its eight neighbours are heads, empty cells never change.
+//
+// text encoding is the one of the Generations states, ' ' empty, 'x' head, 'B' tail and
+// 'C' conductor, so the row kernel count the heads like live cells.
+
+// ------------------ Data type -----------
+
+// rule name of Golly and MCell
+var WireWorld = Rule{wireworld: true}
+
+const (
+	wire_head      = 'x'
+	wire_tail      = 'B'
+	wire_conductor = 'C'
+)
+
+// -------------------- problem solving functions ----------------------
+
+// next cell byte from the number of heads around it
+func wireworld_cell(cell byte, heads int) byte {
+	switch cell {
+	case wire_head:
+		return wire_tail
+	case wire_tail:
+		return wire_conductor
+	case wire_conductor:
+		if heads == 1 || heads == 2 {
+			return wire_head
+		}
+		return wire_conductor
+	}
+	return ' '
+}
+
+// ------------  circuit text -----------
+
+// characters of the circuit text format, index is the state
+const wire_chars = " Ht."
+
+// read circuit in the usual text format, eg:
+//	tH.........
+//	.   .
+//	   ...
+//	.   .
+//	Ht.. ......
+// ' ' empty, 'H' head, 't' tail and '.' conductor, a short line is empty on the right
+func read_wire(rd io.Reader) (Pattern, error) {
+	scanner := bufio.NewScanner(rd)
+
+	lines := []string{}
+	width := 0
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if len(line) > width {
+			width = len(line)
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return Pattern{}, err
+	}
+
+	p := NewPattern(width, len(lines))
+	p.rule = WireWorld.String()
+	for i, line := range lines {
+		for k, c := range []byte(line) {
+			state := strings.IndexByte(wire_chars, c)
+			if state < 0 {
+				return Pattern{}, fmt.Errorf("Invalid circuit: unexpected %q in line %d", c, i + 1)
+			}
+			p.cells[i][k] = state_cell(state)
+		}
+	}
+
+	return p, nil
+}
+
+// write the board in circuit text format, trailing empty cells of a row are not written
+func write_wire(w io.Writer, b Board) error {
+	if !b.rule.wireworld {
+		return errors.New("Circuit output need the WireWorld rule")
+	}
+	bw := bufio.NewWriter(w)
+	for i := 0; i < b.height; i++ {
+		row := make([]byte, b.width)
+		for k := 0; k < b.width; k++ {
+			// bytes of no Wireworld state are empty like in the kernel
+			state := board_state(b, i, k)
+			if state >= len(wire_chars) {
+				state = 0
+			}
+			row[k] = wire_chars[state]
+		}
+		fmt.Fprintln(bw, strings.TrimRight(string(row), " "))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// diode from left to right, the two cells above and below the gap stop a signal from the right
+var wire_diode = []string{
+	"    ..",
+	"..... ......",
+	"    ..",
+}
+
+// exclusive or of the wires A (top) and B (bottom), two heads side by side kill each other
+var wire_xor = []string{
+	".....",
+	"     .",
+	"    ....",
+	"    .  ......",
+	"    ....",
+	"     .",
+	".....",
+}
+
+func new_circuit(t *testing.T, rows []string) Board {
+	p, err := read_wire(strings.NewReader(strings.Join(rows, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	board, err := place_pattern(p, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	board.rule, _ = parse_rule(p.rule)
+	return board
+}
+
+// send an electron "tH" at the start of the wire, the tail at col and the head toward col + dir
+func send_electron(b Board, row, col, dir int) {
+	b.data[row][col], b.data[row][col + dir] = wire_tail, wire_head
+}
+
+// generations until cell [row,col] is a head, -1 if it is not within steps
+func first_head(b Board, row, col, steps int) int {
+	pool := NewWorkerPool(4)
+	defer pool.close()
+	for g := 0; g <= steps; g++ {
+		if g > 0 {
+			b = play_parallel(b, pool.in, pool.out)
+		}
+		if b.data[row][col] == wire_head {
+			return g
+		}
+	}
+	return -1
+}
+
+func Test_parse_wireworld_rule(t *testing.T) {
+	rule, err := parse_rule(" wireworld ")
+	if err != nil || !rule.wireworld || rule.String() != "WireWorld" || rule.states() != 4 {
+		t.Errorf("got %v %v", rule, err)
+	}
+	if rule, _ := parse_rule("B3/S23"); rule.wireworld {
+		t.Error("B3/S23 is not WireWorld")
+	}
+}
+
+func Test_wireworld_cell(t *testing.T) {
+	cases := []struct {
+		cell  byte
+		heads int
+		want  byte
+	}{
+		{' ', 2, ' '},
+		{wire_head, 1, wire_tail},
+		{wire_tail, 1, wire_conductor},
+		{wire_conductor, 0, wire_conductor},
+		{wire_conductor, 1, wire_head},
+		{wire_conductor, 2, wire_head},
+		{wire_conductor, 3, wire_conductor},
+	}
+	for _, tc := range cases {
+		if got := wireworld_cell(tc.cell, tc.heads); got != tc.want {
+			t.Errorf("%q with %d heads: got %q, want %q", tc.cell, tc.heads, got, tc.want)
+		}
+	}
+}
+
+// straight forward per cell Wireworld step as a reference for the row chunk kernel
+func play_wireworld_reference(b Board) Board {
+	dst := NewBoard(b.width, b.height)
+	dst.boundary, dst.rule = b.boundary, b.rule
+	for r := 0; r < b.height; r++ {
+		for c := 0; c < b.width; c++ {
+			heads := 0
+			for dr := -1; dr <= 1; dr++ {
+				for dc := -1; dc <= 1; dc++ {
+					nr, nc := r + dr, c + dc
+					if b.boundary == BoundaryWrap {
+						nr, nc = (nr + b.height) % b.height, (nc + b.width) % b.width
+					}
+					if (dr != 0 || dc != 0) && nr >= 0 && nr < b.height && nc >= 0 && nc < b.width && cell_state(b.data[nr][nc]) == 1 {
+						heads++
+					}
+				}
+			}
+
+			next := 0
+			switch cell_state(b.data[r][c]) {
+			case 1:
+				next = 2
+			case 2:
+				next = 3
+			case 3:
+				next = 3
+				if heads == 1 || heads == 2 {
+					next = 1
+				}
+			}
+			dst.data[r][c] = state_cell(next)
+		}
+	}
+	return dst
+}
+
+func Test_wireworld_parallel(t *testing.T) {
+	rnd := rand.New(rand.NewSource(4))
+	for _, mode := range []Boundary{BoundaryDead, BoundaryWrap} {
+		// mostly conductor so the electrons keep running
+		board := NewBoard(29, 19)
+		board.rule, board.boundary = WireWorld, mode
+		for i := range board.data {
+			for k := range board.data[i] {
+				board.data[i][k] = state_cell([]int{0, 1, 2, 3, 3, 3}[rnd.Intn(6)])
+			}
+		}
+
+		want := board
+		for i := 0; i < 20; i++ {
+			want = play_wireworld_reference(want)
+		}
+		for _, got := range []Board{run_parallel(board, 20), run_parallel_full(board, 20)} {
+			if strings.Join(board_rows(got), "\n") != strings.Join(board_rows(want), "\n") {
+				t.Errorf("boundary %d: parallel result differ from reference", mode)
+			}
+			// the heads are the live cells
+			stats := board_stats(got)
+			if got.stats.population != stats.population || got.stats.hash != stats.hash {
+				t.Errorf("boundary %d: merge stats %+v, board stats %+v", mode, got.stats, stats)
+			}
+		}
+	}
+}
+
+func Test_wireworld_diode(t *testing.T) {
+	// forward the electron run one cell per generation as on a plain wire, 10 cells in 10 generations
+	board := new_circuit(t, wire_diode)
+	send_electron(board, 1, 0, 1)
+	if got := first_head(board, 1, 11, 40); got != 10 {
+		t.Errorf("forward: head at the output in generation %d, want 10", got)
+	}
+
+	// backward it reach the gap in 6 generations and stop there
+	board = new_circuit(t, wire_diode)
+	send_electron(board, 1, 11, -1)
+	if got := first_head(board, 1, 4, 40); got != 6 {
+		t.Errorf("backward: head at the gap in generation %d, want 6", got)
+	}
+	if got := first_head(board, 1, 0, 40); got != -1 {
+		t.Errorf("backward: head at the input in generation %d, want none", got)
+	}
+}
+
+func Test_wireworld_xor(t *testing.T) {
+	cases := []struct {
+		a, b bool
+		want int // generation of the head at the output end
+	}{
+		{false, false, -1},
+		{true, false, 11},
+		{false, true, 11},
+		{true, true, -1},
+	}
+	for _, tc := range cases {
+		board := new_circuit(t, wire_xor)
+		if tc.a {
+			send_electron(board, 0, 0, 1)
+		}
+		if tc.b {
+			send_electron(board, 6, 0, 1)
+		}
+		if got := first_head(board, 3, 12, 40); got != tc.want {
+			t.Errorf("A %v B %v: head at the output in generation %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+
+	// the inputs meet in the middle of the output wire in generation 6 and cancel
+	board := new_circuit(t, wire_xor)
+	send_electron(board, 0, 0, 1)
+	send_electron(board, 6, 0, 1)
+	got := board_rows(run_parallel(board, 6))
+	for i := range got {
+		got[i] = strings.TrimRight(got[i], " ")
+	}
+	if got[2] != "    BBBx" || got[3] != "    C  xCCCCC" || got[4] != "    BBBx" {
+		t.Errorf("generation 6:\n%s", strings.Join(got, "\n"))
+	}
+	if got := run_parallel(board, 8); got.stats.population != 0 {
+		t.Errorf("generation 8: got %d heads, want none", got.stats.population)
+	}
+}
+
+func Test_wire_round_trip(t *testing.T) {
+	text := "tH.........\n.   .\n   ...\n.   .\nHt.. ......\n"
+	p, err := read_wire(strings.NewReader(text))
+	if err != nil || p.width != 11 || p.height != 5 || p.rule != "WireWorld" {
+		t.Fatalf("got %dx%d %q %v", p.width, p.height, p.rule, err)
+	}
+	if string(p.cells[0][:3]) != "BxC" || string(p.cells[4][:5]) != "xBCC " {
+		t.Errorf("cells: %q", p.cells)
+	}
+
+	board, _ := place_pattern(p, 0, 0, 0, 0)
+	board.rule = WireWorld
+	buf := &bytes.Buffer{}
+	if err := write_board(buf, board, "wire"); err != nil || buf.String() != text {
+		t.Errorf("got %q %v, want %q", buf.String(), err, text)
+	}
+
+	// generation 1 of the Rosetta Code example
+	buf.Reset()
+	write_wire(buf, run_parallel(board, 1))
+	if want := ".tH........\nH   .\n   ...\nH   .\nt... ......\n"; buf.String() != want {
+		t.Errorf("generation 1: got %q, want %q", buf.String(), want)
+	}
+
+	if _, err := read_wire(strings.NewReader("tH..x\n")); err == nil {
+		t.Error("expected error for an unknown cell")
+	}
+	board.rule = Conway
+	if err := write_board(buf, board, "wire"); err == nil {
+		t.Error("expected error for a life-like rule")
+	}
+}
+
+func Test_wire_rle_round_trip(t *testing.T) {
+	// head A, tail B and conductor C like in Golly
+	board := new_circuit(t, wire_xor)
+	send_electron(board, 0, 0, 1)
+	buf := &bytes.Buffer{}
+	if err := write_board(buf, board, "rle"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "x = 13, y = 7, rule = WireWorld\nBA3C$5.C$4.4C$4.C2.6C$4.4C$5.C$5C!\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	p, err := read_rle(buf)
+	if err != nil || p.rule != "WireWorld" {
+		t.Fatal(p.rule, err)
+	}
+	again, _ := place_pattern(p, 0, 0, 0, 0)
+	if strings.Join(board_rows(again), "\n") != strings.Join(board_rows(board), "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(board_rows(again), "\n"), strings.Join(board_rows(board), "\n"))
+	}
+}
+
+func Test_wireworld_unsupported(t *testing.T) {
+	board := new_circuit(t, wire_diode)
+	if _, err := NewEngine(board, "bit", 4); err == nil {
+		t.Error("bit kernel: expected error")
+	}
+	if _, err := NewHashLife(board); err == nil {
+		t.Error("hashlife: expected error")
+	}
+	if _, err := NewSparseFromBoard(board); err == nil {
+		t.Error("sparse: expected error")
+	}
+	if err := write_board(&bytes.Buffer{}, board, "cells"); err == nil {
+		t.Error("cells output: expected error")
+	}
+}